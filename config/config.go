@@ -2,20 +2,229 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/go-playground/validator"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	UserSvcPort      string `mapstructure:"UserSvcPort" validate:"required"`
-	AuthSvcPort      string `mapstructure:"AuthSvcPort" validate:"required"`
-	MovieBookingPort string `mapstructure:"MovieBookingPort" validate:"required"`
-	PaymentPort      string `mapstructure:"PaymentPort" validate:"required"`
+	UserSvcPort       string `mapstructure:"UserSvcPort" validate:"required_without=UserSvcAddr"`
+	UserSvcAddr       string `mapstructure:"UserSvcAddr"`
+	UserSvcTLS        bool   `mapstructure:"UserSvcTLS"`
+	UserSvcCAFile     string `mapstructure:"UserSvcCAFile"`
+	UserSvcCertFile   string `mapstructure:"UserSvcCertFile"`
+	UserSvcKeyFile    string `mapstructure:"UserSvcKeyFile"`
+	UserSvcServerName string `mapstructure:"UserSvcServerName"`
+
+	AuthSvcPort       string `mapstructure:"AuthSvcPort" validate:"required_without=AuthSvcAddr"`
+	AuthSvcAddr       string `mapstructure:"AuthSvcAddr"`
+	AuthSvcTLS        bool   `mapstructure:"AuthSvcTLS"`
+	AuthSvcCAFile     string `mapstructure:"AuthSvcCAFile"`
+	AuthSvcCertFile   string `mapstructure:"AuthSvcCertFile"`
+	AuthSvcKeyFile    string `mapstructure:"AuthSvcKeyFile"`
+	AuthSvcServerName string `mapstructure:"AuthSvcServerName"`
+
+	MovieBookingPort       string `mapstructure:"MovieBookingPort" validate:"required_without=MovieBookingAddr"`
+	MovieBookingAddr       string `mapstructure:"MovieBookingAddr"`
+	MovieBookingTLS        bool   `mapstructure:"MovieBookingTLS"`
+	MovieBookingCAFile     string `mapstructure:"MovieBookingCAFile"`
+	MovieBookingCertFile   string `mapstructure:"MovieBookingCertFile"`
+	MovieBookingKeyFile    string `mapstructure:"MovieBookingKeyFile"`
+	MovieBookingServerName string `mapstructure:"MovieBookingServerName"`
+
+	PaymentPort       string `mapstructure:"PaymentPort" validate:"required_without=PaymentAddr"`
+	PaymentAddr       string `mapstructure:"PaymentAddr"`
+	PaymentTLS        bool   `mapstructure:"PaymentTLS"`
+	PaymentCAFile     string `mapstructure:"PaymentCAFile"`
+	PaymentCertFile   string `mapstructure:"PaymentCertFile"`
+	PaymentKeyFile    string `mapstructure:"PaymentKeyFile"`
+	PaymentServerName string `mapstructure:"PaymentServerName"`
+
+	JWTMode           string        `mapstructure:"JWTMode" validate:"oneof=remote local hybrid"`
+	JWTSecret         string        `mapstructure:"JWTSecret"`
+	JWTJWKSFile       string        `mapstructure:"JWTJWKSFile"`
+	JWTJWKSURL        string        `mapstructure:"JWTJWKSURL"`
+	JWTJWKSRefresh    time.Duration `mapstructure:"JWTJWKSRefresh"`
+	JWTIssuer         string        `mapstructure:"JWTIssuer"`
+	JWTLeeway         time.Duration `mapstructure:"JWTLeeway"`
+	JWTRoleClaim      string        `mapstructure:"JWTRoleClaim"`
+	JWTUserIDClaim    string        `mapstructure:"JWTUserIDClaim"`
+	JWTEmailClaim     string        `mapstructure:"JWTEmailClaim"`
+	JWTUserRole       string        `mapstructure:"JWTUserRole"`
+	JWTAdminRole      string        `mapstructure:"JWTAdminRole"`
+	JWTSuperAdminRole string        `mapstructure:"JWTSuperAdminRole"`
+
+	RabbitMQURL          string        `mapstructure:"RABBITMQ_URL" validate:"required"`
+	RabbitMQReconnectMin time.Duration `mapstructure:"RabbitMQReconnectMin"`
+	RabbitMQReconnectMax time.Duration `mapstructure:"RabbitMQReconnectMax"`
+
+	HttpAddr            string        `mapstructure:"HttpAddr" validate:"required"`
+	ShutdownTimeout     time.Duration `mapstructure:"ShutdownTimeout"`
+	ReadinessDrainDelay time.Duration `mapstructure:"ReadinessDrainDelay"`
+
+	PolicyFile string `mapstructure:"PolicyFile"`
+	// PolicyDryRun overrides the policy file's dry_run when set, either way.
+	PolicyDryRun *bool `mapstructure:"PolicyDryRun"`
+
+	RateLimitFile string `mapstructure:"RateLimitFile"`
+	// RateLimitAPIKeys is a comma separated list of API keys that get their
+	// own bucket on api_key rules. Other callers are limited by IP.
+	RateLimitAPIKeys string `mapstructure:"RateLimitAPIKeys"`
+	// TrustedProxies is a comma separated list of proxy CIDRs whose
+	// X-Forwarded-For is believed when resolving client IPs.
+	TrustedProxies string `mapstructure:"TrustedProxies"`
+
+	LoginFreeAttempts       int           `mapstructure:"LoginFreeAttempts"`
+	LoginBackoffBase        time.Duration `mapstructure:"LoginBackoffBase"`
+	LoginBackoffMax         time.Duration `mapstructure:"LoginBackoffMax"`
+	LoginLockoutThreshold   int           `mapstructure:"LoginLockoutThreshold"`
+	LoginIPLockoutThreshold int           `mapstructure:"LoginIPLockoutThreshold"`
+	LoginLockoutDuration    time.Duration `mapstructure:"LoginLockoutDuration"`
+	LoginFailureWindow      time.Duration `mapstructure:"LoginFailureWindow"`
+
+	RazorpayKeySecret     string `mapstructure:"RazorpayKeySecret"`
+	RazorpayWebhookSecret string `mapstructure:"RazorpayWebhookSecret"`
+	// RazorpayWebhookDedupeTTL is how long webhook event IDs are remembered.
+	// They are kept in memory, so deduplication needs a single replica.
+	RazorpayWebhookDedupeTTL time.Duration `mapstructure:"RazorpayWebhookDedupeTTL"`
+
+	// TransactionOwnerTTL is how long the booking behind a transaction is
+	// remembered for payment status ownership checks.
+	TransactionOwnerTTL time.Duration `mapstructure:"TransactionOwnerTTL"`
+
+	IdempotencyTTL time.Duration `mapstructure:"IdempotencyTTL"`
+
+	SeatHoldTTL time.Duration `mapstructure:"SeatHoldTTL"`
+	// SeatHoldPaymentTTL is how long a hold is kept once a payment order is
+	// attached, which should cover Razorpay's checkout timeout.
+	SeatHoldPaymentTTL    time.Duration `mapstructure:"SeatHoldPaymentTTL"`
+	SeatHoldSweepInterval time.Duration `mapstructure:"SeatHoldSweepInterval"`
+
+	SeatEventsExchange   string `mapstructure:"SeatEventsExchange"`
+	SeatEventsBindingKey string `mapstructure:"SeatEventsBindingKey"`
+	SeatStreamHistory    int    `mapstructure:"SeatStreamHistory"`
+
+	CancellationFile string `mapstructure:"CancellationFile"`
+
+	// TicketSigningKey is a base64 encoded Ed25519 seed used to sign e-ticket
+	// QR codes. Tickets are not issued without it.
+	TicketSigningKey string `mapstructure:"TicketSigningKey"`
+
+	HelpDeskQueue   string        `mapstructure:"HelpDeskQueue"`
+	HelpDeskTimeout time.Duration `mapstructure:"HelpDeskTimeout"`
+	// HelpDeskExchange carries agent messages to users ("user.<id>") and
+	// user messages to agents ("agent.<id>").
+	HelpDeskExchange string        `mapstructure:"HelpDeskExchange"`
+	HelpDeskInboxTTL time.Duration `mapstructure:"HelpDeskInboxTTL"`
+	HelpDeskHistory  int           `mapstructure:"HelpDeskHistory"`
+
+	// ChatAllowedOrigins is a comma separated list of origins allowed to open
+	// the help-desk socket. Empty allows same-origin pages only.
+	ChatAllowedOrigins  string        `mapstructure:"ChatAllowedOrigins"`
+	ChatPingInterval    time.Duration `mapstructure:"ChatPingInterval"`
+	ChatIdleTimeout     time.Duration `mapstructure:"ChatIdleTimeout"`
+	ChatMaxMessageSize  int64         `mapstructure:"ChatMaxMessageSize"`
+	ChatMaxConnsPerUser int           `mapstructure:"ChatMaxConnsPerUser"`
+
+	// EventsExchange receives domain events routed by type, such as
+	// "booking.created". EventsOutboxFile holds events the broker has not
+	// confirmed yet and must be on a volume that survives restarts; events
+	// failing EventsOutboxMaxAttempts flushes move to EventsOutboxFile.dead.
+	EventsExchange          string        `mapstructure:"EventsExchange"`
+	EventsOutboxFile        string        `mapstructure:"EventsOutboxFile"`
+	EventsOutboxMaxAttempts int           `mapstructure:"EventsOutboxMaxAttempts"`
+	EventsRetries           int           `mapstructure:"EventsRetries"`
+	EventsRetryBackoff      time.Duration `mapstructure:"EventsRetryBackoff"`
+	EventsConfirmTimeout    time.Duration `mapstructure:"EventsConfirmTimeout"`
+	EventsFlushInterval     time.Duration `mapstructure:"EventsFlushInterval"`
+	EventsQueueSize         int           `mapstructure:"EventsQueueSize"`
+}
+
+// Backend describes how to reach one upstream gRPC service. Target accepts
+// anything grpc.Dial understands (host:port, dns:///host:port) as well as
+// static:///host1:port,host2:port for a fixed list of addresses.
+type Backend struct {
+	Target     string
+	TLS        bool
+	CAFile     string
+	CertFile   string
+	KeyFile    string
+	ServerName string
 }
 
 var envs = []string{
-	"UserSvcPort", "AuthSvcPort", "MovieBookingPort", "PaymentPort",
+	"UserSvcPort", "UserSvcAddr", "UserSvcTLS", "UserSvcCAFile", "UserSvcCertFile", "UserSvcKeyFile", "UserSvcServerName",
+	"AuthSvcPort", "AuthSvcAddr", "AuthSvcTLS", "AuthSvcCAFile", "AuthSvcCertFile", "AuthSvcKeyFile", "AuthSvcServerName",
+	"MovieBookingPort", "MovieBookingAddr", "MovieBookingTLS", "MovieBookingCAFile", "MovieBookingCertFile", "MovieBookingKeyFile", "MovieBookingServerName",
+	"PaymentPort", "PaymentAddr", "PaymentTLS", "PaymentCAFile", "PaymentCertFile", "PaymentKeyFile", "PaymentServerName",
+	"JWTMode", "JWTSecret", "JWTJWKSFile", "JWTJWKSURL", "JWTJWKSRefresh", "JWTIssuer", "JWTLeeway",
+	"JWTRoleClaim", "JWTUserIDClaim", "JWTEmailClaim", "JWTUserRole", "JWTAdminRole", "JWTSuperAdminRole",
+	"RABBITMQ_URL", "RabbitMQReconnectMin", "RabbitMQReconnectMax",
+	"HttpAddr", "ShutdownTimeout", "ReadinessDrainDelay",
+	"PolicyFile", "PolicyDryRun",
+	"RateLimitFile", "RateLimitAPIKeys", "TrustedProxies",
+	"LoginFreeAttempts", "LoginBackoffBase", "LoginBackoffMax", "LoginLockoutThreshold", "LoginIPLockoutThreshold",
+	"LoginLockoutDuration", "LoginFailureWindow",
+	"RazorpayKeySecret", "RazorpayWebhookSecret", "RazorpayWebhookDedupeTTL",
+	"IdempotencyTTL", "TransactionOwnerTTL",
+	"SeatHoldTTL", "SeatHoldPaymentTTL", "SeatHoldSweepInterval",
+	"SeatEventsExchange", "SeatEventsBindingKey", "SeatStreamHistory",
+	"CancellationFile",
+	"TicketSigningKey",
+	"HelpDeskQueue", "HelpDeskTimeout", "HelpDeskExchange", "HelpDeskInboxTTL", "HelpDeskHistory",
+	"ChatAllowedOrigins", "ChatPingInterval", "ChatIdleTimeout", "ChatMaxMessageSize", "ChatMaxConnsPerUser",
+	"EventsExchange", "EventsOutboxFile", "EventsOutboxMaxAttempts", "EventsRetries", "EventsRetryBackoff", "EventsConfirmTimeout", "EventsFlushInterval", "EventsQueueSize",
+}
+
+var defaults = map[string]interface{}{
+	"JWTMode":                  "remote",
+	"JWTJWKSRefresh":           "10m",
+	"JWTLeeway":                "30s",
+	"JWTRoleClaim":             "role",
+	"JWTUserIDClaim":           "user_id",
+	"JWTEmailClaim":            "email",
+	"JWTUserRole":              "user",
+	"JWTAdminRole":             "admin",
+	"JWTSuperAdminRole":        "superadmin",
+	"RabbitMQReconnectMin":     "500ms",
+	"RabbitMQReconnectMax":     "30s",
+	"HttpAddr":                 ":8080",
+	"ShutdownTimeout":          "30s",
+	"ReadinessDrainDelay":      "5s",
+	"LoginFreeAttempts":        3,
+	"LoginBackoffBase":         "1s",
+	"LoginBackoffMax":          "1m",
+	"LoginLockoutThreshold":    10,
+	"LoginIPLockoutThreshold":  50,
+	"LoginLockoutDuration":     "15m",
+	"LoginFailureWindow":       "15m",
+	"RazorpayWebhookDedupeTTL": "24h",
+	"IdempotencyTTL":           "24h",
+	"TransactionOwnerTTL":      "168h",
+	"SeatHoldTTL":              "10m",
+	"SeatHoldPaymentTTL":       "20m",
+	"SeatHoldSweepInterval":    "15s",
+	"SeatEventsExchange":       "booking.events",
+	"SeatEventsBindingKey":     "seat.#",
+	"SeatStreamHistory":        256,
+	"HelpDeskQueue":            "chat_queue",
+	"HelpDeskTimeout":          "30s",
+	"HelpDeskExchange":         "helpdesk",
+	"HelpDeskInboxTTL":         "24h",
+	"HelpDeskHistory":          100,
+	"ChatPingInterval":         "30s",
+	"ChatIdleTimeout":          "75s",
+	"ChatMaxMessageSize":       4096,
+	"ChatMaxConnsPerUser":      3,
+	"EventsExchange":           "gateway.events",
+	"EventsOutboxFile":         "events.outbox",
+	"EventsOutboxMaxAttempts":  10,
+	"EventsRetries":            3,
+	"EventsRetryBackoff":       "200ms",
+	"EventsConfirmTimeout":     "5s",
+	"EventsFlushInterval":      "30s",
+	"EventsQueueSize":          1024,
 }
 
 func LoadConfig() (Config, error) {
@@ -24,6 +233,10 @@ func LoadConfig() (Config, error) {
 	viper.SetConfigFile(".env")
 	viper.AutomaticEnv()
 
+	for key, value := range defaults {
+		viper.SetDefault(key, value)
+	}
+
 	if err := viper.ReadInConfig(); err != nil {
 		return cfg, fmt.Errorf("error reading config file: %w", err)
 	}
@@ -45,3 +258,55 @@ func LoadConfig() (Config, error) {
 
 	return cfg, nil
 }
+
+func (c Config) UserSvc() Backend {
+	return Backend{
+		Target:     target(c.UserSvcAddr, "user-admin-svc.default.svc.cluster.local", c.UserSvcPort),
+		TLS:        c.UserSvcTLS,
+		CAFile:     c.UserSvcCAFile,
+		CertFile:   c.UserSvcCertFile,
+		KeyFile:    c.UserSvcKeyFile,
+		ServerName: c.UserSvcServerName,
+	}
+}
+
+func (c Config) AuthSvc() Backend {
+	return Backend{
+		Target:     target(c.AuthSvcAddr, "auth-svc.default.svc.cluster.local", c.AuthSvcPort),
+		TLS:        c.AuthSvcTLS,
+		CAFile:     c.AuthSvcCAFile,
+		CertFile:   c.AuthSvcCertFile,
+		KeyFile:    c.AuthSvcKeyFile,
+		ServerName: c.AuthSvcServerName,
+	}
+}
+
+func (c Config) MovieBookingSvc() Backend {
+	return Backend{
+		Target:     target(c.MovieBookingAddr, "movies-booking-svc.default.svc.cluster.local", c.MovieBookingPort),
+		TLS:        c.MovieBookingTLS,
+		CAFile:     c.MovieBookingCAFile,
+		CertFile:   c.MovieBookingCertFile,
+		KeyFile:    c.MovieBookingKeyFile,
+		ServerName: c.MovieBookingServerName,
+	}
+}
+
+func (c Config) PaymentSvc() Backend {
+	return Backend{
+		Target:     target(c.PaymentAddr, "payment-svc.default.svc.cluster.local", c.PaymentPort),
+		TLS:        c.PaymentTLS,
+		CAFile:     c.PaymentCAFile,
+		CertFile:   c.PaymentCertFile,
+		KeyFile:    c.PaymentKeyFile,
+		ServerName: c.PaymentServerName,
+	}
+}
+
+// target keeps the in-cluster default host when only a port is configured.
+func target(addr, defaultHost, port string) string {
+	if addr != "" {
+		return addr
+	}
+	return defaultHost + ":" + port
+}
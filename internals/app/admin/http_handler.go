@@ -8,18 +8,21 @@ import (
 	"time"
 
 	"github.com/aparnasukesh/api-gateway/pkg/common"
+	"github.com/aparnasukesh/api-gateway/pkg/lockout"
 	"github.com/gin-gonic/gin"
 )
 
 type Handler struct {
 	svc         Service
 	authHandler common.Middleware
+	logins      *lockout.Guard
 }
 
-func NewHttpHandler(svc Service, authHandler common.Middleware) *Handler {
+func NewHttpHandler(svc Service, authHandler common.Middleware, logins *lockout.Guard) *Handler {
 	return &Handler{
 		svc:         svc,
 		authHandler: authHandler,
+		logins:      logins,
 	}
 }
 
@@ -28,7 +31,7 @@ func (h *Handler) MountRoutes(r *gin.RouterGroup) {
 	r.POST("/login", h.logIn)
 	r.POST("/forgot/password", h.forgotPassword)
 	r.POST("/reset/password", h.resetPassword)
-	auth := r.Use(h.authHandler.AdminAuthMiddleware())
+	auth := r.Use(h.authHandler.AdminAuthMiddleware(), h.authHandler.RateLimit(), h.authHandler.Authorize())
 
 	auth.GET("/profile/:id", h.getAdminProfile)
 	auth.PUT("/profile/:id", h.updateAdminProfile)
@@ -88,16 +91,14 @@ func (h *Handler) MountRoutes(r *gin.RouterGroup) {
 
 // Seats
 func (h *Handler) createSeats(ctx *gin.Context) {
-	authorization := ctx.Request.Header.Get("Authorization")
-	userId, err := h.svc.GetUserIDFromToken(ctx, authorization)
-	if err != nil {
-		h.responseWithError(ctx, http.StatusUnauthorized, errors.New("unauthorized: Invalid token or user ID extraction failed"))
+	principal, ok := common.RequirePrincipal(ctx)
+	if !ok {
 		return
 	}
+	userId := principal.UserID
 	var req CreateSeatsRequest
 	if err := ctx.BindJSON(&req); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	validRowRegex := regexp.MustCompile(`^[A-Z]$`)
@@ -111,10 +112,9 @@ func (h *Handler) createSeats(ctx *gin.Context) {
 			return
 		}
 	}
-	err = h.svc.CreateSeats(ctx, req, userId)
+	err := h.svc.CreateSeats(ctx, req, userId)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "Seats created successfully")
@@ -128,14 +128,12 @@ func (h *Handler) getSeatByScreenId(ctx *gin.Context) {
 	}
 	screenId, err := strconv.Atoi(screenIdstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	seats, err := h.svc.GetSeatsByScreenId(ctx, screenId)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -150,14 +148,12 @@ func (h *Handler) getSeatById(ctx *gin.Context) {
 	}
 	seatId, err := strconv.Atoi(seatIdstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	seat, err := h.svc.GetSeatById(ctx, seatId)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -173,14 +169,12 @@ func (h *Handler) getSeatBySeatNumberAndScreenId(ctx *gin.Context) {
 	}
 	screenId, err := strconv.Atoi(screenIdstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	seat, err := h.svc.GetSeatBySeatNumberAndScreenId(ctx, screenId, seatNumber)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -195,14 +189,12 @@ func (h *Handler) deleteSeatById(ctx *gin.Context) {
 	}
 	seatId, err := strconv.Atoi(seatIdstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err = h.svc.DeleteSeatById(ctx, seatId)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -218,14 +210,12 @@ func (h *Handler) deleteSeatBySeatNumberAndScreenId(ctx *gin.Context) {
 	}
 	screenId, err := strconv.Atoi(screenIdstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err = h.svc.DeleteSeatBySeatNumberAndScreenId(ctx, screenId, seatNumber)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -234,23 +224,20 @@ func (h *Handler) deleteSeatBySeatNumberAndScreenId(ctx *gin.Context) {
 
 // Movie Schedule
 func (h *Handler) addMovieSchedule(ctx *gin.Context) {
-	authorization := ctx.Request.Header.Get("Authorization")
-	userId, err := h.svc.GetUserIDFromToken(ctx, authorization)
-	if err != nil {
-		h.responseWithError(ctx, http.StatusUnauthorized, errors.New("unauthorized: Invalid token or user ID extraction failed"))
+	principal, ok := common.RequirePrincipal(ctx)
+	if !ok {
 		return
 	}
+	userId := principal.UserID
 	var movieSchedule MovieSchedule
 	if err := ctx.BindJSON(&movieSchedule); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	err = h.svc.AddMovieSchedule(ctx, movieSchedule, userId)
+	err := h.svc.AddMovieSchedule(ctx, movieSchedule, userId)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -261,27 +248,23 @@ func (h *Handler) updateMovieSchedule(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
-	authorization := ctx.Request.Header.Get("Authorization")
-	userId, err := h.svc.GetUserIDFromToken(ctx, authorization)
-	if err != nil {
-		h.responseWithError(ctx, http.StatusUnauthorized, errors.New("unauthorized: Invalid token or user ID extraction failed"))
+	principal, ok := common.RequirePrincipal(ctx)
+	if !ok {
 		return
 	}
+	userId := principal.UserID
 	var updateData MovieSchedule
 	if err := ctx.BindJSON(&updateData); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
 	err = h.svc.UpdateMovieSchedule(ctx, id, updateData, userId)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -291,8 +274,7 @@ func (h *Handler) updateMovieSchedule(ctx *gin.Context) {
 func (h *Handler) getAllMovieSchedules(ctx *gin.Context) {
 	movieSchedules, err := h.svc.GetAllMovieSchedules(ctx)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -303,14 +285,12 @@ func (h *Handler) getMovieScheduleByMovieID(ctx *gin.Context) {
 	movieIDstr := ctx.Query("movieid")
 	movieID, err := strconv.Atoi(movieIDstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	movieSchedules, err := h.svc.GetMovieScheduleByMovieID(ctx, movieID)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -321,14 +301,12 @@ func (h *Handler) getMovieScheduleByTheaterID(ctx *gin.Context) {
 	theaterIDstr := ctx.Query("theaterid")
 	theaterID, err := strconv.Atoi(theaterIDstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	movieSchedules, err := h.svc.GetMovieScheduleByTheaterID(ctx, theaterID)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -340,20 +318,17 @@ func (h *Handler) getMovieScheduleByMovieIdAndTheaterId(ctx *gin.Context) {
 	theaterIDstr := ctx.Query("theaterid")
 	movieID, err := strconv.Atoi(movieIDstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	theaterID, err := strconv.Atoi(theaterIDstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	movieSchedules, err := h.svc.GetMovieScheduleByMovieIdAndTheaterId(ctx, movieID, theaterID)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -365,20 +340,17 @@ func (h *Handler) getMovieScheduleByMovieIdAndShowTimeId(ctx *gin.Context) {
 	showTimeIDstr := ctx.Query("showtimeid")
 	showTimeID, err := strconv.Atoi(showTimeIDstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	movieID, err := strconv.Atoi(movieIDstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	movieSchedules, err := h.svc.GetMovieScheduleByMovieIdAndShowTimeId(ctx, movieID, showTimeID)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "movie schedule details retrieved successfully", movieSchedules)
@@ -389,20 +361,17 @@ func (h *Handler) getMovieScheduleByTheaterIdAndShowTimeId(ctx *gin.Context) {
 	showTimeIDstr := ctx.Query("showtimeid")
 	showTimeID, err := strconv.Atoi(showTimeIDstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	theaterID, err := strconv.Atoi(theaterIDstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	movieSchedules, err := h.svc.GetMovieScheduleByTheaterIdAndShowTimeId(ctx, theaterID, showTimeID)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -413,14 +382,12 @@ func (h *Handler) getMovieScheduleByID(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	movieSchedule, err := h.svc.GetMovieScheduleByID(ctx, id)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "movie schedule details retrieved successfully", movieSchedule)
@@ -430,14 +397,12 @@ func (h *Handler) deleteMovieScheduleById(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err = h.svc.DeleteMovieScheduleById(ctx, id)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -449,20 +414,17 @@ func (h *Handler) deleteMovieScheduleByMovieIdAndTheaterId(ctx *gin.Context) {
 	theaterIDstr := ctx.Query("theaterid")
 	movieID, err := strconv.Atoi(movieIDstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	theaterID, err := strconv.Atoi(theaterIDstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err = h.svc.DeleteMovieScheduleByMovieIdAndTheaterId(ctx, movieID, theaterID)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -475,26 +437,22 @@ func (h *Handler) deleteMovieScheduleByMovieIdAndTheaterIdAndShowTimeId(ctx *gin
 	showTimeIDstr := ctx.Query("showtimeid")
 	movieID, err := strconv.Atoi(movieIDstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	theaterID, err := strconv.Atoi(theaterIDstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	showTimeID, err := strconv.Atoi(showTimeIDstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err = h.svc.DeleteMovieScheduleByMovieIdAndTheaterIdAndShowTimeId(ctx, movieID, theaterID, showTimeID)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -505,18 +463,15 @@ func (h *Handler) deleteMovieScheduleByMovieIdAndTheaterIdAndShowTimeId(ctx *gin
 func (h *Handler) register(ctx *gin.Context) {
 	userData := Admin{}
 	if err := ctx.BindJSON(&userData); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	if err := ValidateAdmin(userData); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	if err := h.svc.Register(ctx.Request.Context(), &userData); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "admin registration is pending approval.")
@@ -525,14 +480,18 @@ func (h *Handler) register(ctx *gin.Context) {
 func (h *Handler) logIn(ctx *gin.Context) {
 	userData := Admin{}
 	if err := ctx.ShouldBindJSON(&userData); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	attempt, blocked := h.logins.Begin(userData.Email, ctx.ClientIP())
+	if blocked != nil {
+		lockout.Respond(ctx, blocked)
 		return
 	}
 	token, err := h.svc.Login(ctx, &userData)
+	attempt.End(err)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "login succesfull", token)
@@ -542,14 +501,12 @@ func (h *Handler) getAdminProfile(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	admin, err := h.svc.GetAdminProfile(ctx, id)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get admin profile successfull", admin)
@@ -560,20 +517,17 @@ func (h *Handler) updateAdminProfile(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	admin := &AdminProfileDetails{}
 	if err := ctx.ShouldBindJSON(&admin); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err = h.svc.UpdateAdminProfile(ctx, id, *admin)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "update admin profile successfull")
@@ -583,14 +537,12 @@ func (h *Handler) updateAdminProfile(ctx *gin.Context) {
 func (h *Handler) forgotPassword(ctx *gin.Context) {
 	email := ForgotPassword{}
 	if err := ctx.ShouldBindJSON(&email); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err := h.svc.ForgotPassword(ctx, email.Email)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "otp send successfull")
@@ -599,14 +551,12 @@ func (h *Handler) forgotPassword(ctx *gin.Context) {
 func (h *Handler) resetPassword(ctx *gin.Context) {
 	data := ResetPassword{}
 	if err := ctx.ShouldBindJSON(&data); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err := h.svc.ResetPassword(ctx, data)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "password reset successfull")
@@ -614,23 +564,20 @@ func (h *Handler) resetPassword(ctx *gin.Context) {
 
 // Theater
 func (h *Handler) addTheater(ctx *gin.Context) {
-	authorization := ctx.Request.Header.Get("Authorization")
-	userId, err := h.svc.GetUserIDFromToken(ctx, authorization)
-	if err != nil {
-		h.responseWithError(ctx, http.StatusUnauthorized, errors.New("unauthorized: Invalid token or user ID extraction failed"))
+	principal, ok := common.RequirePrincipal(ctx)
+	if !ok {
 		return
 	}
+	userId := principal.UserID
 	theater := &Theater{}
 	if err := ctx.ShouldBindJSON(&theater); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	theater.OwnerID = uint(userId)
-	err = h.svc.AddTheater(ctx, *theater)
+	err := h.svc.AddTheater(ctx, *theater)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "theater added successfully")
@@ -640,18 +587,12 @@ func (h *Handler) deleteTheaterByID(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err = h.svc.DeleteTheaterByID(ctx, id)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		if formattedError == "record not found" {
-			h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
-		} else {
-			h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
-		}
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "theater deleted successfully")
@@ -661,12 +602,7 @@ func (h *Handler) deleteTheaterByName(ctx *gin.Context) {
 	theaterName := ctx.DefaultQuery("name", "")
 	err := h.svc.DeleteTheaterByName(ctx, theaterName)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		if formattedError == "record not found" {
-			h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
-		} else {
-			h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
-		}
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "theater deleted successfully")
@@ -676,14 +612,12 @@ func (h *Handler) getTheaterByID(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	theater, err := h.svc.GetTheaterByID(ctx, id)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get theater details successfully", theater)
@@ -693,8 +627,7 @@ func (h *Handler) getTheaterByName(ctx *gin.Context) {
 	name := ctx.DefaultQuery("name", "")
 	theater, err := h.svc.GetTheaterByName(ctx, name)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get theater details successfully", theater)
@@ -704,31 +637,23 @@ func (h *Handler) updateTheater(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
-	authorization := ctx.Request.Header.Get("Authorization")
-	userId, err := h.svc.GetUserIDFromToken(ctx, authorization)
-	if err != nil {
-		h.responseWithError(ctx, http.StatusUnauthorized, errors.New("unauthorized: Invalid token or user ID extraction failed"))
+	principal, ok := common.RequirePrincipal(ctx)
+	if !ok {
 		return
 	}
+	userId := principal.UserID
 	theater := &Theater{}
 	if err := ctx.ShouldBindJSON(&theater); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	theater.OwnerID = uint(userId)
 	err = h.svc.UpdateTheater(ctx, id, *theater)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		if formattedError == "record not found" {
-			h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
-		} else {
-			h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
-		}
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "theater updated successfully")
@@ -737,8 +662,7 @@ func (h *Handler) updateTheater(ctx *gin.Context) {
 func (h *Handler) listTheaters(ctx *gin.Context) {
 	theaters, err := h.svc.ListTheaters(ctx)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNoContent, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "list theaters successfully", theaters)
@@ -748,8 +672,7 @@ func (h *Handler) listTheaters(ctx *gin.Context) {
 func (h *Handler) listMovies(ctx *gin.Context) {
 	movies, err := h.svc.ListMovies(ctx)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "list movies succesfully", movies)
@@ -759,8 +682,7 @@ func (h *Handler) listMovies(ctx *gin.Context) {
 func (h *Handler) listTheaterTypes(ctx *gin.Context) {
 	theaterTypes, err := h.svc.ListTheaterTypes(ctx)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "list theater-types succesfully", theaterTypes)
@@ -771,8 +693,7 @@ func (h *Handler) listTheaterTypes(ctx *gin.Context) {
 func (h *Handler) listScreenTypes(ctx *gin.Context) {
 	screenTypes, err := h.svc.ListScreenTypes(ctx)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "list screen-types successfully", screenTypes)
@@ -782,8 +703,7 @@ func (h *Handler) listScreenTypes(ctx *gin.Context) {
 func (h *Handler) listSeatCategories(ctx *gin.Context) {
 	seatCategories, err := h.svc.ListSeatCategories(ctx)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "list seat-categories successfully", seatCategories)
@@ -791,22 +711,19 @@ func (h *Handler) listSeatCategories(ctx *gin.Context) {
 
 // Theater screen
 func (h *Handler) addTheaterScreen(ctx *gin.Context) {
-	authorization := ctx.Request.Header.Get("Authorization")
-	userId, err := h.svc.GetUserIDFromToken(ctx, authorization)
-	if err != nil {
-		h.responseWithError(ctx, http.StatusUnauthorized, errors.New("unauthorized: Invalid token or user ID extraction failed"))
+	principal, ok := common.RequirePrincipal(ctx)
+	if !ok {
 		return
 	}
+	userId := principal.UserID
 	theaterScreen := &TheaterScreen{}
 	if err := ctx.ShouldBindJSON(&theaterScreen); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
-	err = h.svc.AddTheaterScreen(ctx, userId, *theaterScreen)
+	err := h.svc.AddTheaterScreen(ctx, userId, *theaterScreen)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "theater screen added successfully")
@@ -816,14 +733,12 @@ func (h *Handler) deleteTheaterScreenByID(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err = h.svc.DeleteTheaterScreenByID(ctx, id)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "theater screen deleted successfully")
@@ -832,14 +747,12 @@ func (h *Handler) deleteTheaterScreenByID(ctx *gin.Context) {
 func (h *Handler) deleteTheaterScreenByNumber(ctx *gin.Context) {
 	theaterscreen := &TheaterScreen{}
 	if err := ctx.ShouldBindJSON(&theaterscreen); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err := h.svc.DeleteTheaterScreenByNumber(ctx, theaterscreen.TheaterID, theaterscreen.ScreenNumber)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "theater screen deleted successfully")
@@ -849,14 +762,12 @@ func (h *Handler) getTheaterScreenByID(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	theaterScreen, err := h.svc.GetTheaterScreenByID(ctx, id)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get theater screen details successfully", theaterScreen)
@@ -867,20 +778,17 @@ func (h *Handler) getTheaterScreenByNumber(ctx *gin.Context) {
 	screenNumberstr := ctx.DefaultQuery("screenNumber", "")
 	theaterID, err := strconv.Atoi(theaterIDstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	screenNumber, err := strconv.Atoi(screenNumberstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	theaterScreen, err := h.svc.GetTheaterScreenByNumber(ctx, theaterID, screenNumber)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get theater screen details successfully", theaterScreen)
@@ -890,26 +798,22 @@ func (h *Handler) updateTheaterScreen(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
-	authorization := ctx.Request.Header.Get("Authorization")
-	userId, err := h.svc.GetUserIDFromToken(ctx, authorization)
-	if err != nil {
-		h.responseWithError(ctx, http.StatusUnauthorized, errors.New("unauthorized: Invalid token or user ID extraction failed"))
+	principal, ok := common.RequirePrincipal(ctx)
+	if !ok {
 		return
 	}
+	userId := principal.UserID
 	theaterScreen := &TheaterScreen{}
 	if err := ctx.ShouldBindJSON(&theaterScreen); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err = h.svc.UpdateTheaterScreen(ctx, id, userId, *theaterScreen)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "theater screen updated successfully")
@@ -918,14 +822,12 @@ func (h *Handler) updateTheaterScreen(ctx *gin.Context) {
 func (h *Handler) listTheaterScreens(ctx *gin.Context) {
 	theaterScreen := &TheaterScreen{}
 	if err := ctx.ShouldBindJSON(&theaterScreen); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	theaterScreens, err := h.svc.ListTheaterScreens(ctx, theaterScreen.TheaterID)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "list theater screens successfully", theaterScreens)
@@ -933,22 +835,19 @@ func (h *Handler) listTheaterScreens(ctx *gin.Context) {
 
 // Show times
 func (h *Handler) addShowtime(ctx *gin.Context) {
-	authorization := ctx.Request.Header.Get("Authorization")
-	userId, err := h.svc.GetUserIDFromToken(ctx, authorization)
-	if err != nil {
-		h.responseWithError(ctx, http.StatusUnauthorized, errors.New("unauthorized: Invalid token or user ID extraction failed"))
+	principal, ok := common.RequirePrincipal(ctx)
+	if !ok {
 		return
 	}
+	userId := principal.UserID
 	showtime := &Showtime{}
 	if err := ctx.ShouldBindJSON(&showtime); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
-	err = h.svc.AddShowtime(ctx, *showtime, userId)
+	err := h.svc.AddShowtime(ctx, *showtime, userId)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "showtime added successfully")
@@ -958,14 +857,12 @@ func (h *Handler) deleteShowtimeByID(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err = h.svc.DeleteShowtimeByID(ctx, id)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "showtime deleted successfully")
@@ -984,8 +881,7 @@ func (h *Handler) deleteShowtimeByDetails(ctx *gin.Context) {
 
 	err := h.svc.DeleteShowtimeByDetails(ctx, movieID, screenID, showDate, showTime)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "showtime deleted successfully")
@@ -995,14 +891,12 @@ func (h *Handler) getShowtimeByID(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	showtime, err := h.svc.GetShowtimeByID(ctx, id)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get showtime details successfully", showtime)
@@ -1021,8 +915,7 @@ func (h *Handler) getShowtimeByDetails(ctx *gin.Context) {
 
 	showtime, err := h.svc.GetShowtimeByDetails(ctx, movieID, screenID, showDate, showTime)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get showtime details successfully", showtime)
@@ -1032,26 +925,22 @@ func (h *Handler) updateShowtime(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
-	authorization := ctx.Request.Header.Get("Authorization")
-	userId, err := h.svc.GetUserIDFromToken(ctx, authorization)
-	if err != nil {
-		h.responseWithError(ctx, http.StatusUnauthorized, errors.New("unauthorized: Invalid token or user ID extraction failed"))
+	principal, ok := common.RequirePrincipal(ctx)
+	if !ok {
 		return
 	}
+	userId := principal.UserID
 	showtime := &Showtime{}
 	if err := ctx.ShouldBindJSON(&showtime); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err = h.svc.UpdateShowtime(ctx, id, *showtime, userId)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "showtime updated successfully")
@@ -1063,8 +952,7 @@ func (h *Handler) listShowtimes(ctx *gin.Context) {
 
 	showtimes, err := h.svc.ListShowtimes(ctx, movieID)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "list showtimes successfully", showtimes)
@@ -2,10 +2,8 @@ package admin
 
 import (
 	"context"
-	"strings"
 	"time"
 
-	"github.com/aparnasukesh/inter-communication/auth"
 	"github.com/aparnasukesh/inter-communication/user_admin"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -13,7 +11,6 @@ import (
 type Service interface {
 	Register(ctx context.Context, signUpData *Admin) error
 	Login(ctx context.Context, loginData *Admin) (string, error)
-	GetUserIDFromToken(ctx context.Context, authorization string) (int, error)
 	GetAdminProfile(ctx context.Context, id int) (*Admin, error)
 	UpdateAdminProfile(ctx context.Context, id int, admin AdminProfileDetails) error
 	ForgotPassword(ctx context.Context, email string) error
@@ -74,13 +71,11 @@ type Service interface {
 
 type service struct {
 	userAdmin user_admin.AdminServiceClient
-	auth      auth.JWT_TokenServiceClient
 }
 
-func NewService(pb user_admin.AdminServiceClient, auth auth.JWT_TokenServiceClient) Service {
+func NewService(pb user_admin.AdminServiceClient) Service {
 	return &service{
 		userAdmin: pb,
-		auth:      auth,
 	}
 }
 
@@ -183,21 +178,6 @@ func (s *service) GetSeatsByScreenId(ctx context.Context, screenId int) ([]Seat,
 }
 
 // Admin
-func (s *service) GetUserIDFromToken(ctx context.Context, authorization string) (int, error) {
-	tokenParts := strings.Split(authorization, "Bearer ")
-	token := tokenParts[1]
-	var userId int
-
-	response, err := s.auth.GetUserID(ctx, &auth.GetUserIDRequest{
-		Token: token,
-	})
-	userId = int(response.UserId)
-	if err != nil {
-		return 0, err
-	}
-	return userId, nil
-}
-
 func (s *service) Register(ctx context.Context, signUpData *Admin) error {
 	reqData := user_admin.RegisterAdminRequest{
 		Username:  signUpData.Username,
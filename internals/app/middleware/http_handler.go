@@ -1,66 +1,74 @@
 package middleware
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"net/http"
 
 	"github.com/aparnasukesh/api-gateway/pkg/common"
+	"github.com/aparnasukesh/api-gateway/pkg/policy"
+	"github.com/aparnasukesh/api-gateway/pkg/ratelimit"
 	"github.com/gin-gonic/gin"
 )
 
 type Handler struct {
-	svc Service
+	svc     Service
+	policy  *policy.Policy
+	limiter *ratelimit.Limiter
 }
 
-func NewHttpHandler(svc Service) common.Middleware {
+// NewHttpHandler builds the auth middlewares. A nil policy disables Authorize
+// and a nil limiter disables RateLimit.
+func NewHttpHandler(svc Service, routePolicy *policy.Policy, limiter *ratelimit.Limiter) common.Middleware {
 	return &Handler{
-		svc: svc,
+		svc:     svc,
+		policy:  routePolicy,
+		limiter: limiter,
 	}
 }
 func (h *Handler) UserAuthMiddleware() gin.HandlerFunc {
-	return func(ctx *gin.Context) {
-		authorization := ctx.Request.Header.Get("Authorization")
-		if authorization == "" {
-			h.responseWithError(ctx, http.StatusUnauthorized, fmt.Errorf("authorization header is missing"))
-			ctx.Abort()
-			return
-		}
+	return h.authenticate(h.svc.UserAuthentication)
+}
 
-		err := h.svc.UserAuthentication(ctx, authorization)
-		if err != nil {
-			formattedError := ExtractErrorMessage(err)
-			h.responseWithError(ctx, http.StatusUnauthorized, errors.New(formattedError))
-			ctx.Abort()
-			return
-		}
+func (h *Handler) AdminAuthMiddleware() gin.HandlerFunc {
+	return h.authenticate(h.svc.AdminAuthentication)
+}
 
-		ctx.Next()
-	}
+func (h *Handler) SuperAdminAuthMiddleware() gin.HandlerFunc {
+	return h.authenticate(h.svc.SuperAdminAuthentication)
 }
-func (h *Handler) AdminAuthMiddleware() gin.HandlerFunc {
-	return func(ctx *gin.Context) {
-		authorization := ctx.Request.Header.Get("Authorization")
-		if err := h.svc.AdminAuthentication(ctx, authorization); err != nil {
-			formattedError := ExtractErrorMessage(err)
-			h.responseWithError(ctx, http.StatusUnauthorized, errors.New(formattedError))
-			ctx.Abort()
-			return
-		}
-		ctx.Next()
 
+// Authorize evaluates the route policy against the principal set by the
+// preceding auth middleware.
+func (h *Handler) Authorize() gin.HandlerFunc {
+	if h.policy == nil {
+		return func(ctx *gin.Context) {
+			ctx.Next()
+		}
 	}
+	return h.policy.Middleware()
 }
 
-func (h *Handler) SuperAdminAuthMiddleware() gin.HandlerFunc {
+// RateLimit applies the rate limits keyed by user, which the global limiter
+// skips because it runs before authentication.
+func (h *Handler) RateLimit() gin.HandlerFunc {
+	return h.limiter.Middleware()
+}
+
+func (h *Handler) authenticate(check func(ctx context.Context, token string) (*common.Principal, error)) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorization := ctx.Request.Header.Get("Authorization")
-		if err := h.svc.SuperAdminAuthentication(ctx, authorization); err != nil {
-			formattedError := ExtractErrorMessage(err)
-			h.responseWithError(ctx, http.StatusUnauthorized, errors.New(formattedError))
-			ctx.Abort()
+		if authorization == "" {
+			h.responseWithError(ctx, http.StatusUnauthorized, fmt.Errorf("authorization header is missing"))
+			return
+		}
+
+		principal, err := check(ctx, authorization)
+		if err != nil {
+			h.responseWithError(ctx, http.StatusUnauthorized, err)
 			return
 		}
+		common.SetPrincipal(ctx, principal)
 		ctx.Next()
 	}
 }
@@ -70,27 +78,20 @@ func (h *Handler) UserPaymentAuthorization() gin.HandlerFunc {
 
 		token, err := ctx.Cookie("UserAuthorization")
 		if err != nil {
-			ctx.JSON(http.StatusUnauthorized, gin.H{
-				"Success": false,
-				"Message": "Authorization failed",
-				"Error":   err.Error(),
-			})
-			ctx.Abort()
+			h.responseWithError(ctx, http.StatusUnauthorized, err)
 			return
 		}
 		if token == "" {
 			h.responseWithError(ctx, http.StatusUnauthorized, fmt.Errorf("authorization header is missing"))
-			ctx.Abort()
 			return
 		}
 
-		err = h.svc.UserAuthentication(ctx, token)
+		principal, err := h.svc.UserAuthentication(ctx, token)
 		if err != nil {
-			formattedError := ExtractErrorMessage(err)
-			h.responseWithError(ctx, http.StatusUnauthorized, errors.New(formattedError))
-			ctx.Abort()
+			h.responseWithError(ctx, http.StatusUnauthorized, err)
 			return
 		}
+		common.SetPrincipal(ctx, principal)
 		ctx.Next()
 	}
 }
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"github.com/aparnasukesh/api-gateway/pkg/common"
 	"github.com/gin-gonic/gin"
 )
 
@@ -17,8 +18,8 @@ func (h Handler) responseWithData(ctx *gin.Context, statusCode int, msg string,
 	})
 }
 
+// responseWithError maps gRPC status errors to their HTTP status and uses
+// statusCode only for errors that carry no usable gRPC code.
 func (h Handler) responseWithError(ctx *gin.Context, statusCode int, err error) {
-	ctx.JSON(statusCode, gin.H{
-		"error": err.Error(),
-	})
+	common.ResponseWithError(ctx, statusCode, err)
 }
@@ -2,53 +2,153 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"strings"
 
+	"github.com/aparnasukesh/api-gateway/pkg/common"
+	"github.com/aparnasukesh/api-gateway/pkg/jwt"
 	pb "github.com/aparnasukesh/inter-communication/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Service interface {
-	UserAuthentication(ctx context.Context, token string) error
-	AdminAuthentication(ctx context.Context, token string) error
-	SuperAdminAuthentication(ctx context.Context, token string) error
+	UserAuthentication(ctx context.Context, token string) (*common.Principal, error)
+	AdminAuthentication(ctx context.Context, token string) (*common.Principal, error)
+	SuperAdminAuthentication(ctx context.Context, token string) (*common.Principal, error)
 }
 
 type service struct {
 	userSvc       pb.UserAuthServiceClient
 	adminSvc      pb.AdminAuthServiceClient
 	superAdminSvc pb.SuperAdminAuthServiceClient
+	tokenSvc      pb.JWT_TokenServiceClient
+	claims        ClaimNames
 }
 
-func NewService(userSvc pb.UserAuthServiceClient, adminSvc pb.AdminAuthServiceClient, superAdminSvc pb.SuperAdminAuthServiceClient) Service {
+// ClaimNames names the token claims read into the principal and the role
+// claim value each protected route group expects.
+type ClaimNames struct {
+	Role       string
+	UserID     string
+	Email      string
+	User       string
+	Admin      string
+	SuperAdmin string
+}
+
+func NewService(userSvc pb.UserAuthServiceClient, adminSvc pb.AdminAuthServiceClient, superAdminSvc pb.SuperAdminAuthServiceClient, tokenSvc pb.JWT_TokenServiceClient, claims ClaimNames) Service {
 	return &service{
 		userSvc:       userSvc,
 		adminSvc:      adminSvc,
 		superAdminSvc: superAdminSvc,
+		tokenSvc:      tokenSvc,
+		claims:        claims,
 	}
 }
 
-func (s *service) UserAuthentication(ctx context.Context, token string) error {
+func (s *service) UserAuthentication(ctx context.Context, token string) (*common.Principal, error) {
 	if _, err := s.userSvc.UserAuthRequired(ctx, &pb.AuthRequest{
 		Token: token,
 	}); err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return s.principal(ctx, token, common.RoleUser)
 }
 
-func (s *service) AdminAuthentication(ctx context.Context, token string) error {
+func (s *service) AdminAuthentication(ctx context.Context, token string) (*common.Principal, error) {
 	if _, err := s.adminSvc.AdminAuthRequired(ctx, &pb.AuthRequest{
 		Token: token,
 	}); err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return s.principal(ctx, token, common.RoleAdmin)
 }
 
-func (s *service) SuperAdminAuthentication(ctx context.Context, token string) error {
+func (s *service) SuperAdminAuthentication(ctx context.Context, token string) (*common.Principal, error) {
 	if _, err := s.superAdminSvc.SuperAdminAuthRequired(ctx, &pb.AuthRequest{
 		Token: token,
 	}); err != nil {
-		return err
+		return nil, err
+	}
+	return s.principal(ctx, token, common.RoleSuperAdmin)
+}
+
+// principal builds the caller identity for a token auth-svc has accepted.
+// The auth check does not return the user ID, so it is asked for only when a
+// handler needs it. Email and expiry stay empty: the gateway cannot verify
+// the token itself in this mode.
+func (s *service) principal(ctx context.Context, token, role string) (*common.Principal, error) {
+	return common.NewDeferredPrincipal(role, func() (int, error) {
+		res, err := s.tokenSvc.GetUserID(ctx, &pb.GetUserIDRequest{
+			Token: bearerToken(token),
+		})
+		if err != nil {
+			return 0, err
+		}
+		return int(res.UserId), nil
+	}), nil
+}
+
+type localService struct {
+	verifier *jwt.Verifier
+	claims   ClaimNames
+	remote   Service
+}
+
+// NewLocalService verifies tokens in-process. When remote is non-nil, tokens
+// that fail local verification for any reason other than expiry are checked
+// against auth-svc instead of being rejected.
+func NewLocalService(verifier *jwt.Verifier, claims ClaimNames, remote Service) Service {
+	return &localService{
+		verifier: verifier,
+		claims:   claims,
+		remote:   remote,
 	}
-	return nil
+}
+
+func (s *localService) UserAuthentication(ctx context.Context, token string) (*common.Principal, error) {
+	return s.authenticate(token, s.claims.User, common.RoleUser, func() (*common.Principal, error) {
+		return s.remote.UserAuthentication(ctx, token)
+	})
+}
+
+func (s *localService) AdminAuthentication(ctx context.Context, token string) (*common.Principal, error) {
+	return s.authenticate(token, s.claims.Admin, common.RoleAdmin, func() (*common.Principal, error) {
+		return s.remote.AdminAuthentication(ctx, token)
+	})
+}
+
+func (s *localService) SuperAdminAuthentication(ctx context.Context, token string) (*common.Principal, error) {
+	return s.authenticate(token, s.claims.SuperAdmin, common.RoleSuperAdmin, func() (*common.Principal, error) {
+		return s.remote.SuperAdminAuthentication(ctx, token)
+	})
+}
+
+func (s *localService) authenticate(token, claimRole, role string, remoteCheck func() (*common.Principal, error)) (*common.Principal, error) {
+	claims, err := s.verifier.Verify(bearerToken(token))
+	if err != nil {
+		if s.remote == nil || errors.Is(err, jwt.ErrExpired) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return remoteCheck()
+	}
+	if claims.String(s.claims.Role) != claimRole {
+		return nil, status.Error(codes.PermissionDenied, "insufficient role for this resource")
+	}
+	userId, ok := claims.Int(s.claims.UserID)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "token has no user id")
+	}
+	principal := &common.Principal{
+		UserID: userId,
+		Role:   role,
+		Email:  claims.String(s.claims.Email),
+	}
+	principal.ExpiresAt, _ = claims.Time("exp")
+	return principal, nil
+}
+
+func bearerToken(authorization string) string {
+	return strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
 }
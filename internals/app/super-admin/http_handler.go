@@ -1,30 +1,32 @@
 package superadmin
 
 import (
-	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/aparnasukesh/api-gateway/pkg/common"
+	"github.com/aparnasukesh/api-gateway/pkg/lockout"
 	"github.com/gin-gonic/gin"
 )
 
 type Handler struct {
 	svc         Service
 	authHandler common.Middleware
+	logins      *lockout.Guard
 }
 
-func NewHttpHandler(svc Service, auth common.Middleware) *Handler {
+func NewHttpHandler(svc Service, auth common.Middleware, logins *lockout.Guard) *Handler {
 	return &Handler{
 		svc:         svc,
 		authHandler: auth,
+		logins:      logins,
 	}
 }
 
 func (h *Handler) MountRoutes(r *gin.RouterGroup) {
 	r.POST("/login", h.logIn)
 
-	auth := r.Use(h.authHandler.SuperAdminAuthMiddleware())
+	auth := r.Use(h.authHandler.SuperAdminAuthMiddleware(), h.authHandler.RateLimit(), h.authHandler.Authorize())
 
 	auth.GET("/admin/requests", h.listAdminRequests)
 	auth.PUT("/admin/approval", h.adminApproval)
@@ -70,14 +72,18 @@ func (h *Handler) MountRoutes(r *gin.RouterGroup) {
 func (h *Handler) logIn(ctx *gin.Context) {
 	userData := Admin{}
 	if err := ctx.ShouldBindJSON(&userData); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	attempt, blocked := h.logins.Begin(userData.Email, ctx.ClientIP())
+	if blocked != nil {
+		lockout.Respond(ctx, blocked)
 		return
 	}
 	token, err := h.svc.Login(ctx, &userData)
+	attempt.End(err)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "login succesfull", token)
@@ -87,8 +93,7 @@ func (h *Handler) logIn(ctx *gin.Context) {
 func (h *Handler) listAdminRequests(ctx *gin.Context) {
 	adminLists, err := h.svc.ListAdminRequests(ctx)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 
 	}
@@ -98,15 +103,13 @@ func (h *Handler) listAdminRequests(ctx *gin.Context) {
 func (h *Handler) adminApproval(ctx *gin.Context) {
 	approval := &AdminApproval{}
 	if err := ctx.ShouldBindJSON(&approval); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 
 	}
 	err := h.svc.AdminApproval(ctx, approval.Email, approval.IsVerified)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 
 	}
@@ -116,8 +119,7 @@ func (h *Handler) adminApproval(ctx *gin.Context) {
 func (h *Handler) listAllAdmins(ctx *gin.Context) {
 	admins, err := h.svc.ListAllAdmins(ctx)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "list admins succesfully", admins)
@@ -128,14 +130,12 @@ func (h *Handler) getAdminById(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	admin, err := h.svc.GetAdminById(ctx, id)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get admin details successfull", admin)
@@ -145,14 +145,12 @@ func (h *Handler) getAdminById(ctx *gin.Context) {
 func (h *Handler) registerMovie(ctx *gin.Context) {
 	movie := &Movie{}
 	if err := ctx.ShouldBindJSON(&movie); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	movieId, err := h.svc.RegisterMovie(ctx, *movie)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "movie successfully created", movieId)
@@ -163,19 +161,16 @@ func (h *Handler) updateMovie(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
 	if err := ctx.ShouldBindJSON(&movie); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	if err := h.svc.UpdateMovie(ctx, *movie, id); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotModified, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "movie updated succesfully")
@@ -184,8 +179,7 @@ func (h *Handler) updateMovie(ctx *gin.Context) {
 func (h *Handler) listMovies(ctx *gin.Context) {
 	movies, err := h.svc.ListMovies(ctx)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "list movies succesfully", movies)
@@ -195,14 +189,12 @@ func (h *Handler) getMovieDetails(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	movie, err := h.svc.GetMovieDetails(ctx, id)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get movie details succesfully", movie)
@@ -212,18 +204,12 @@ func (h *Handler) deleteMovie(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err = h.svc.DeleteMovie(ctx, id)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		if formattedError == "record not found" {
-			h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
-		} else {
-			h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
-		}
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "Movie deleted successfully")
@@ -233,14 +219,12 @@ func (h *Handler) deleteMovie(ctx *gin.Context) {
 func (h *Handler) addTheaterType(ctx *gin.Context) {
 	theaterType := &TheaterType{}
 	if err := ctx.ShouldBindJSON(&theaterType); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err := h.svc.AddTheaterType(ctx, *theaterType)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "theater type addedd successfully")
@@ -250,18 +234,12 @@ func (h *Handler) deleteTheaterTypeById(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err = h.svc.DeleteTheaterTypeById(ctx, id)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		if formattedError == "record not found" {
-			h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
-		} else {
-			h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
-		}
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "theater type deleted successfully")
@@ -271,12 +249,7 @@ func (h *Handler) deleteTheaterTypeByName(ctx *gin.Context) {
 	theaterName := ctx.DefaultQuery("name", "")
 	err := h.svc.DeleteTheaterTypeByName(ctx, theaterName)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		if formattedError == "record not found" {
-			h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
-		} else {
-			h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
-		}
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "theater type  deleted succesfully")
@@ -286,14 +259,12 @@ func (h *Handler) getTheaterTypeByID(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	theaterType, err := h.svc.GetTheaterTypeByID(ctx, id)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get theater-type details succesfully", theaterType)
@@ -303,8 +274,7 @@ func (h *Handler) getTheaterTypeByName(ctx *gin.Context) {
 	name := ctx.DefaultQuery("name", "")
 	theaterType, err := h.svc.GetTheaterTypeByName(ctx, name)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get theater-type details succesfully", theaterType)
@@ -314,24 +284,17 @@ func (h *Handler) updateTheaterType(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	theatertype := &TheaterType{}
 	if err := ctx.ShouldBindJSON(&theatertype); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err = h.svc.UpdateTheaterType(ctx, id, *theatertype)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		if formattedError == "record not found" {
-			h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
-		} else {
-			h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
-		}
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -342,8 +305,7 @@ func (h *Handler) updateTheaterType(ctx *gin.Context) {
 func (h *Handler) listTheaterTypes(ctx *gin.Context) {
 	theaterTypes, err := h.svc.ListTheaterTypes(ctx)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "list theater-types succesfully", theaterTypes)
@@ -353,14 +315,12 @@ func (h *Handler) listTheaterTypes(ctx *gin.Context) {
 func (h *Handler) addScreenType(ctx *gin.Context) {
 	screenType := &ScreenType{}
 	if err := ctx.ShouldBindJSON(&screenType); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err := h.svc.AddScreenType(ctx, *screenType)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "screen type added successfully")
@@ -370,18 +330,12 @@ func (h *Handler) deleteScreenTypeById(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err = h.svc.DeleteScreenTypeById(ctx, id)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		if formattedError == "record not found" {
-			h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
-		} else {
-			h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
-		}
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "screen type deleted successfully")
@@ -391,12 +345,7 @@ func (h *Handler) deleteScreenTypeByName(ctx *gin.Context) {
 	screenName := ctx.DefaultQuery("name", "")
 	err := h.svc.DeleteScreenTypeByName(ctx, screenName)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		if formattedError == "record not found" {
-			h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
-		} else {
-			h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
-		}
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "screen type deleted successfully")
@@ -406,14 +355,12 @@ func (h *Handler) getScreenTypeByID(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	screenType, err := h.svc.GetScreenTypeByID(ctx, id)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get screen-type details successfully", screenType)
@@ -423,8 +370,7 @@ func (h *Handler) getScreenTypeByName(ctx *gin.Context) {
 	name := ctx.DefaultQuery("name", "")
 	screenType, err := h.svc.GetScreenTypeByName(ctx, name)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get screen-type details successfully", screenType)
@@ -434,24 +380,17 @@ func (h *Handler) updateScreenType(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	screenType := &ScreenType{}
 	if err := ctx.ShouldBindJSON(&screenType); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err = h.svc.UpdateScreenType(ctx, id, *screenType)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		if formattedError == "record not found" {
-			h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
-		} else {
-			h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
-		}
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "update screen type successfully")
@@ -460,8 +399,7 @@ func (h *Handler) updateScreenType(ctx *gin.Context) {
 func (h *Handler) listScreenTypes(ctx *gin.Context) {
 	screenTypes, err := h.svc.ListScreenTypes(ctx)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "list screen-types successfully", screenTypes)
@@ -471,14 +409,12 @@ func (h *Handler) listScreenTypes(ctx *gin.Context) {
 func (h *Handler) addSeatCategory(ctx *gin.Context) {
 	seatCategory := &SeatCategory{}
 	if err := ctx.ShouldBindJSON(&seatCategory); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err := h.svc.AddSeatCategory(ctx, *seatCategory)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "seat category added successfully")
@@ -488,18 +424,12 @@ func (h *Handler) deleteSeatCategoryById(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err = h.svc.DeleteSeatCategoryByID(ctx, id)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		if formattedError == "record not found" {
-			h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
-		} else {
-			h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
-		}
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "seat category deleted successfully")
@@ -509,12 +439,7 @@ func (h *Handler) deleteSeatCategoryByName(ctx *gin.Context) {
 	seatCategoryName := ctx.DefaultQuery("name", "")
 	err := h.svc.DeleteSeatCategoryByName(ctx, seatCategoryName)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		if formattedError == "record not found" {
-			h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
-		} else {
-			h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
-		}
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "seat category deleted successfully")
@@ -524,14 +449,12 @@ func (h *Handler) getSeatCategoryByID(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	seatCategory, err := h.svc.GetSeatCategoryByID(ctx, id)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get seat-category details successfully", seatCategory)
@@ -541,8 +464,7 @@ func (h *Handler) getSeatCategoryByName(ctx *gin.Context) {
 	name := ctx.DefaultQuery("name", "")
 	seatCategory, err := h.svc.GetSeatCategoryByName(ctx, name)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get seat-category details successfully", seatCategory)
@@ -552,24 +474,17 @@ func (h *Handler) updateSeatCategory(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	seatCategory := &SeatCategory{}
 	if err := ctx.ShouldBindJSON(&seatCategory); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err = h.svc.UpdateSeatCategory(ctx, id, *seatCategory)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		if formattedError == "record not found" {
-			h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
-		} else {
-			h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
-		}
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "seat category updated successfully")
@@ -578,8 +493,7 @@ func (h *Handler) updateSeatCategory(ctx *gin.Context) {
 func (h *Handler) listSeatCategories(ctx *gin.Context) {
 	seatCategories, err := h.svc.ListSeatCategories(ctx)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "list seat-categories successfully", seatCategories)
@@ -590,8 +504,7 @@ func (h *Handler) listSeatCategories(ctx *gin.Context) {
 func (h *Handler) listAllUser(ctx *gin.Context) {
 	users, err := h.svc.ListAllUser(ctx)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "list user successfully", users)
@@ -601,14 +514,12 @@ func (h *Handler) getUserByID(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	user, err := h.svc.GetUserByID(ctx, id)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get user successfully", user)
@@ -618,14 +529,12 @@ func (h *Handler) blockUser(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err = h.svc.BlockUser(ctx, id)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "user blocked successfully")
@@ -635,14 +544,12 @@ func (h *Handler) unBlockUser(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err = h.svc.UnBlockUser(ctx, id)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "user unblocked successfully")
@@ -4,7 +4,7 @@ import (
 	"context"
 	"errors"
 
-	"github.com/aparnasukesh/inter-communication/auth"
+	"github.com/aparnasukesh/api-gateway/pkg/rabbitmq"
 	"github.com/aparnasukesh/inter-communication/movie_booking"
 	"github.com/aparnasukesh/inter-communication/user_admin"
 )
@@ -12,6 +12,7 @@ import (
 type service struct {
 	userAdmin    user_admin.SuperAdminServiceClient
 	movieBooking movie_booking.MovieServiceClient
+	events       *rabbitmq.Publisher
 }
 type Service interface {
 	Login(ctx context.Context, loginData *Admin) (string, error)
@@ -56,10 +57,11 @@ type Service interface {
 	ListSeatCategories(ctx context.Context) ([]SeatCategory, error)
 }
 
-func NewService(pb user_admin.SuperAdminServiceClient, auth auth.JWT_TokenServiceClient, movieBooking movie_booking.MovieServiceClient) Service {
+func NewService(pb user_admin.SuperAdminServiceClient, movieBooking movie_booking.MovieServiceClient, events *rabbitmq.Publisher) Service {
 	return &service{
 		userAdmin:    pb,
 		movieBooking: movieBooking,
+		events:       events,
 	}
 }
 func (s *service) Login(ctx context.Context, loginData *Admin) (string, error) {
@@ -96,6 +98,9 @@ func (s *service) AdminApproval(ctx context.Context, email string, isVerified bo
 	if err != nil {
 		return err
 	}
+	if isVerified {
+		s.events.Publish(rabbitmq.AdminApproved{Email: email})
+	}
 	return nil
 }
 
@@ -155,6 +160,13 @@ func (s *service) RegisterMovie(ctx context.Context, movie Movie) (int, error) {
 	if err != nil {
 		return 0, err
 	}
+	s.events.Publish(rabbitmq.MovieRegistered{
+		MovieID:     int(response.MovieId),
+		Title:       movie.Title,
+		Genre:       movie.Genre,
+		Language:    movie.Language,
+		ReleaseDate: movie.ReleaseDate,
+	})
 	return int(response.MovieId), nil
 }
 
@@ -1,29 +1,74 @@
 package user
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
+	"github.com/aparnasukesh/api-gateway/pkg/audit"
+	"github.com/aparnasukesh/api-gateway/pkg/cancellation"
 	"github.com/aparnasukesh/api-gateway/pkg/common"
+	"github.com/aparnasukesh/api-gateway/pkg/helpdesk"
+	"github.com/aparnasukesh/api-gateway/pkg/idempotency"
+	"github.com/aparnasukesh/api-gateway/pkg/lockout"
+	"github.com/aparnasukesh/api-gateway/pkg/pagination"
+	"github.com/aparnasukesh/api-gateway/pkg/rabbitmq"
+	"github.com/aparnasukesh/api-gateway/pkg/razorpay"
+	"github.com/aparnasukesh/api-gateway/pkg/seathold"
+	"github.com/aparnasukesh/api-gateway/pkg/seatstream"
+	"github.com/aparnasukesh/api-gateway/pkg/ticket"
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 )
 
 type Handler struct {
 	svc         Service
 	authHandler common.Middleware
+	logins      *lockout.Guard
+	idempotency *idempotency.Keys
+	seats       *seatstream.Hub
+	chat        *helpdesk.Hub
+	sockets     ChatSocketConfig
+	upgrader    websocket.Upgrader
+	socketsMu   *sync.Mutex
+	openSockets map[int]int
+	closing     chan struct{}
+	closeOnce   *sync.Once
 }
 
-func NewHttpHandler(svc Service, authHandler common.Middleware) *Handler {
+func NewHttpHandler(svc Service, authHandler common.Middleware, logins *lockout.Guard, idempotencyKeys *idempotency.Keys, seats *seatstream.Hub, chat *helpdesk.Hub, sockets ChatSocketConfig) *Handler {
 	return &Handler{
 		svc:         svc,
 		authHandler: authHandler,
+		logins:      logins,
+		idempotency: idempotencyKeys,
+		seats:       seats,
+		chat:        chat,
+		sockets:     sockets,
+		upgrader:    newUpgrader(sockets.AllowedOrigins),
+		socketsMu:   &sync.Mutex{},
+		openSockets: make(map[int]int),
+		closing:     make(chan struct{}),
+		closeOnce:   &sync.Once{},
 	}
 }
 
+// CloseChatSessions asks every open help-desk socket to close with a
+// going-away frame and ends the seat streams. Hijacked connections and
+// streaming responses are not drained by http.Server.
+func (h *Handler) CloseChatSessions() {
+	h.closeOnce.Do(func() {
+		close(h.closing)
+	})
+}
+
 func (h *Handler) MountRoutes(r *gin.RouterGroup) {
 	r.POST("/register", h.register)
 	r.POST("/register/validate", h.registerValidate)
@@ -52,16 +97,22 @@ func (h *Handler) MountRoutes(r *gin.RouterGroup) {
 	r.GET("/theater/screen/available/seats/:screen_id/showtime/:showtime_id", h.listAvailableSeatsbyScreenIDAndShowTimeID)
 	r.GET("/theater/screen/seat/:seat_id", h.getSeatBySeatID)
 
-	auth := r.Use(h.authHandler.UserAuthMiddleware())
+	auth := r.Use(h.authHandler.UserAuthMiddleware(), h.authHandler.RateLimit(), h.authHandler.Authorize())
 	auth.GET("/profile", h.getProfile)
-	auth.PUT("/profile/:id", h.updateUserProfile)
+	auth.PUT("/profile/:id", common.RequireOwnership("id", common.SelfOwned), h.updateUserProfile)
 	//Booking
-	auth.POST("/booking", h.createBooking)
-	auth.GET("/booking/:id", h.getBookingByID)
-	auth.GET("/booking/user/:user_id", h.listBookingsByUser)
+	auth.POST("/showtime/:id/holds", h.holdSeats)
+	auth.DELETE("/showtime/:id/holds/:hold_id", h.releaseHold)
+	auth.GET("/showtime/:id/seats/stream", h.streamSeats)
+
+	auth.POST("/booking", h.idempotency.Middleware(), h.createBooking)
+	auth.GET("/booking/:id", common.RequireOwnership("id", h.svc.BookingOwner), h.getBookingByID)
+	auth.GET("/booking/:id/ticket", common.RequireOwnership("id", h.svc.BookingOwner), h.getTicket)
+	auth.POST("/booking/:id/cancel", common.RequireOwnership("id", h.svc.BookingOwner), h.idempotency.Middleware(), h.cancelBooking)
+	auth.GET("/booking/user/:user_id", common.RequireOwnership("user_id", common.SelfOwned), h.listBookingsByUser)
 	// Payment
-	auth.GET("/payment/status/:transaction_id", h.getTransactionStatus)
-	auth.POST("/payment/:booking_id", h.processPayment)
+	auth.GET("/payment/status/:transaction_id", common.RequireOwnership("transaction_id", h.svc.TransactionOwner), h.getTransactionStatus)
+	auth.POST("/payment/:booking_id", common.RequireOwnership("booking_id", h.svc.BookingOwner), h.idempotency.Middleware(), h.processPayment)
 	auth.PUT("/payment/success", h.paymentSuccess)
 	auth.PUT("/payment/failure", h.paymentFailure)
 	// Chat
@@ -69,54 +120,229 @@ func (h *Handler) MountRoutes(r *gin.RouterGroup) {
 }
 
 // Chat
+
+// helpDeskChat carries JSON envelopes both ways: the user's messages, acks
+// and typing notices in, and agent messages, typing notices and ticket
+// status updates out at any time. A plain text frame is taken as a message.
+// On connect the socket first gets the history after ?since=<message id>;
+// clients drop frames whose id they have already seen. A user message is
+// acked with the id the gateway gave it and the client's own id as ref; at
+// most maxPendingReplies messages per socket wait for the help desk.
 func (h *Handler) helpDeskChat(ctx *gin.Context) {
-	authorization := ctx.Request.Header.Get("Authorization")
-	userId, err := h.svc.GetUserIDFromToken(ctx, authorization)
-	if err != nil {
-		h.responseWithError(ctx, http.StatusUnauthorized, errors.New("unauthorized: Invalid token or user ID extraction failed"))
+	principal, ok := common.RequirePrincipal(ctx)
+	if !ok {
 		return
 	}
+	userId := principal.UserID
 
-	conn, err := NewUpgrader(ctx)
+	if !h.acquireSocket(userId) {
+		h.responseWithError(ctx, http.StatusTooManyRequests, ErrTooManyChatSockets)
+		return
+	}
+	defer h.releaseSocket(userId)
+
+	// Upgrade has already answered the request when it fails.
+	conn, err := h.upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
 	if err != nil {
-		log.Fatal(err)
+		log.Printf("help-desk socket upgrade for user %d failed: %v", userId, err)
+		return
 	}
 	defer conn.Close()
+	conn.SetReadLimit(h.sockets.MaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(h.sockets.IdleTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(h.sockets.IdleTimeout))
+	})
 
-	for {
-		messageType, message, err := conn.ReadMessage()
-		if err != nil {
-			formattedError := ExtractErrorMessage(err)
-			h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+	session, err := h.chat.Join(userId)
+	if err != nil {
+		log.Printf("help-desk session for user %d failed: %v", userId, err)
+		closeSocket(conn, websocket.CloseInternalServerErr, "help desk unavailable")
+		return
+	}
+	defer session.Leave()
+
+	for _, env := range h.chat.History(userId, ctx.Query("since")) {
+		conn.SetWriteDeadline(time.Now().Add(socketWriteWait))
+		if err := conn.WriteJSON(env); err != nil {
 			return
 		}
-		resBody, err := h.svc.HelpDeskChat(ctx, message, userId)
+	}
+
+	// Writes only happen on this goroutine, as gorilla/websocket requires.
+	go func() {
+		ping := time.NewTicker(h.sockets.PingInterval)
+		defer ping.Stop()
+		for {
+			select {
+			case env, ok := <-session.Out:
+				if !ok {
+					// Either the reader below left, or the session fell
+					// behind and the client should reconnect and catch up.
+					closeSocket(conn, websocket.CloseTryAgainLater, "reconnect to resume")
+					return
+				}
+				conn.SetWriteDeadline(time.Now().Add(socketWriteWait))
+				if err := conn.WriteJSON(env); err != nil {
+					conn.Close()
+					return
+				}
+			case <-ping.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(socketWriteWait)); err != nil {
+					conn.Close()
+					return
+				}
+			case <-h.closing:
+				closeSocket(conn, websocket.CloseGoingAway, "server shutting down")
+				return
+			}
+		}
+	}()
+
+	pending := make(chan struct{}, maxPendingReplies)
+	for {
+		_, data, err := conn.ReadMessage()
 		if err != nil {
-			formattedError := ExtractErrorMessage(err)
-			h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+			var netErr net.Error
+			switch {
+			case errors.As(err, &netErr) && netErr.Timeout():
+				closeSocket(conn, websocket.CloseNormalClosure, "idle timeout")
+			case errors.Is(err, websocket.ErrReadLimit):
+				// gorilla/websocket has already sent 1009 message too big.
+			case websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived):
+				log.Printf("help-desk socket for user %d closed: %v", userId, err)
+			}
 			return
 		}
-		if err := conn.WriteMessage(messageType, resBody); err != nil {
-			log.Println("WebSocket write error:", err)
-			break
+		conn.SetReadDeadline(time.Now().Add(h.sockets.IdleTimeout))
+		env := helpdesk.Envelope{}
+		if err := json.Unmarshal(data, &env); err != nil || env.Type == "" {
+			env = helpdesk.Envelope{Type: helpdesk.TypeMessage, Body: string(data)}
+		}
+		switch env.Type {
+		case helpdesk.TypeAck:
+			session.Ack(env.ID)
+		case helpdesk.TypeTyping:
+			env.From = helpdesk.FromUser
+			env.UserID = userId
+			env.SentAt = time.Now().UTC()
+			if err := h.chat.ToAgents(userId, env); err != nil {
+				log.Printf("help-desk typing notice for user %d failed: %v", userId, err)
+			}
+		case helpdesk.TypeMessage:
+			// History deduplicates on the ID, so clients do not choose it.
+			ref := env.ID
+			select {
+			case pending <- struct{}{}:
+			default:
+				session.Send(helpdesk.Envelope{
+					Ref:    ref,
+					Type:   helpdesk.TypeError,
+					From:   helpdesk.FromSystem,
+					Body:   "too many messages waiting for the help desk, please wait for a reply",
+					SentAt: time.Now().UTC(),
+				})
+				continue
+			}
+			env = helpdesk.Envelope{
+				ID:     helpdesk.NewID(),
+				Type:   helpdesk.TypeMessage,
+				From:   helpdesk.FromUser,
+				UserID: userId,
+				Body:   env.Body,
+				SentAt: time.Now().UTC(),
+			}
+			h.chat.Deliver(userId, env, session)
+			if err := h.chat.ToAgents(userId, env); err != nil {
+				log.Printf("help-desk message for user %d not published to agents: %v", userId, err)
+			}
+			session.Send(helpdesk.Envelope{ID: env.ID, Ref: ref, Type: helpdesk.TypeAck, SentAt: time.Now().UTC()})
+			go func(env helpdesk.Envelope) {
+				defer func() { <-pending }()
+				h.helpDeskReply(session, env)
+			}(env)
+		default:
+			session.Send(helpdesk.Envelope{
+				ID:     env.ID,
+				Type:   helpdesk.TypeError,
+				From:   helpdesk.FromSystem,
+				Body:   "unknown frame type " + env.Type,
+				SentAt: time.Now().UTC(),
+			})
 		}
 	}
+}
+
+func (h *Handler) acquireSocket(userId int) bool {
+	h.socketsMu.Lock()
+	defer h.socketsMu.Unlock()
+	if h.sockets.MaxConnsPerUser > 0 && h.openSockets[userId] >= h.sockets.MaxConnsPerUser {
+		return false
+	}
+	h.openSockets[userId]++
+	return true
+}
+
+func (h *Handler) releaseSocket(userId int) {
+	h.socketsMu.Lock()
+	defer h.socketsMu.Unlock()
+	if h.openSockets[userId]--; h.openSockets[userId] <= 0 {
+		delete(h.openSockets, userId)
+	}
+}
 
+// helpDeskReply asks the help-desk service to answer a user message and
+// delivers the answer to every socket of the user.
+func (h *Handler) helpDeskReply(session *helpdesk.Session, env helpdesk.Envelope) {
+	reply, err := h.svc.HelpDeskChat(context.Background(), []byte(env.Body), env.UserID)
+	if err != nil {
+		body := "help desk is unavailable, please try again"
+		if errors.Is(err, rabbitmq.ErrRPCTimeout) {
+			body = "help desk did not respond, please try again"
+		}
+		log.Printf("help-desk reply to %s failed: %v", env.ID, err)
+		session.Send(helpdesk.Envelope{
+			ID:     env.ID,
+			Type:   helpdesk.TypeError,
+			From:   helpdesk.FromSystem,
+			Body:   body,
+			SentAt: time.Now().UTC(),
+		})
+		return
+	}
+	h.chat.Deliver(env.UserID, helpdesk.Envelope{
+		ID:     helpdesk.NewID(),
+		Type:   helpdesk.TypeMessage,
+		From:   helpdesk.FromAgent,
+		UserID: env.UserID,
+		Body:   string(reply),
+		SentAt: time.Now().UTC(),
+	}, nil)
 }
 
 // Payment
 func (h *Handler) paymentSuccess(ctx *gin.Context) {
-	orderId := ctx.Query("order_id")
-	paymentId := ctx.Query("payment_id")
-
 	data := PaymentStatusRequest{
-		OrderID:           orderId,
-		RazorpayPaymentID: paymentId,
+		OrderID:           ctx.Query("order_id"),
+		RazorpayPaymentID: ctx.Query("payment_id"),
+		Signature:         ctx.Query("razorpay_signature"),
+	}
+	if data.OrderID == "" || data.RazorpayPaymentID == "" {
+		h.responseWithError(ctx, http.StatusBadRequest, errors.New("order_id and payment_id are required"))
+		return
 	}
 	err := h.svc.PaymentSuccess(ctx, data)
+	if errors.Is(err, razorpay.ErrMissingSignature) || errors.Is(err, razorpay.ErrInvalidSignature) {
+		audit.Record(ctx, "payment.signature_rejected", map[string]string{
+			"order_id":   data.OrderID,
+			"payment_id": data.RazorpayPaymentID,
+			"reason":     err.Error(),
+		})
+		h.responseWithError(ctx, http.StatusBadRequest, err)
+		return
+	}
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "payment successfull")
@@ -124,40 +350,59 @@ func (h *Handler) paymentSuccess(ctx *gin.Context) {
 }
 
 func (h *Handler) paymentFailure(ctx *gin.Context) {
-	orderId, _ := ctx.Params.Get("order_id")
-	paymentId, _ := ctx.Params.Get("payment_id")
-
-	data := PaymentStatusRequest{
-		OrderID:           orderId,
-		RazorpayPaymentID: paymentId,
+	principal, ok := common.RequirePrincipal(ctx)
+	if !ok {
+		return
+	}
+	data := PaymentFailureRequest{}
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		h.responseWithError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if err := ValidatePaymentFailure(data); err != nil {
+		h.responseWithError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	err := h.svc.PaymentFailure(ctx, principal.UserID, data)
+	if errors.Is(err, ErrOrderNotFound) {
+		h.responseWithError(ctx, http.StatusNotFound, err)
+		return
 	}
-	err := h.svc.PaymentFailure(ctx, data)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "payment failed")
 }
 
 func (h *Handler) processPayment(ctx *gin.Context) {
-	authorization := ctx.Request.Header.Get("Authorization")
-	userId, err := h.svc.GetUserIDFromToken(ctx, authorization)
-	if err != nil {
-		h.responseWithError(ctx, http.StatusUnauthorized, errors.New("unauthorized: Invalid token or user ID extraction failed"))
+	principal, ok := common.RequirePrincipal(ctx)
+	if !ok {
 		return
 	}
+	userId := principal.UserID
 	bookingIdstr := ctx.Param("booking_id")
 	bookingId, err := strconv.Atoi(bookingIdstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	paymentReq := ProcessPaymentRequest{}
+	if err := ctx.ShouldBindJSON(&paymentReq); err != nil {
+		h.responseWithError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if paymentReq.PaymentMethodID == 0 {
+		h.responseWithError(ctx, http.StatusBadRequest, errors.New("payment_method_id is required"))
+		return
+	}
+	transaction, err := h.svc.ProcessPayment(ctx, bookingId, userId, int(paymentReq.PaymentMethodID))
+	if errors.Is(err, ErrUnpricedBooking) || errors.Is(err, ErrBookingPaid) || errors.Is(err, ErrBookingCancelled) {
+		h.responseWithError(ctx, http.StatusConflict, err)
 		return
 	}
-	transaction, err := h.svc.ProcessPayment(ctx, bookingId, userId)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -168,34 +413,185 @@ func (h *Handler) getTransactionStatus(ctx *gin.Context) {
 	idstr := ctx.Param("transaction_id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
 	status, err := h.svc.GetTransactionStatus(ctx, id)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
 	h.responseWithData(ctx, http.StatusOK, "transaction status retrieved", status)
 }
 
+// Seat holds
+func (h *Handler) holdSeats(ctx *gin.Context) {
+	principal, ok := common.RequirePrincipal(ctx)
+	if !ok {
+		return
+	}
+	showtimeId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		h.responseWithError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	holdReq := SeatHoldRequest{}
+	if err := ctx.ShouldBindJSON(&holdReq); err != nil {
+		h.responseWithError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	hold, err := h.svc.HoldSeats(ctx, principal.UserID, showtimeId, holdReq)
+	if errors.Is(err, seathold.ErrSeatsHeld) || errors.Is(err, ErrSeatUnavailable) {
+		h.responseWithError(ctx, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		h.responseWithError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	h.responseWithData(ctx, http.StatusCreated, "seats held", hold)
+}
+
+func (h *Handler) releaseHold(ctx *gin.Context) {
+	principal, ok := common.RequirePrincipal(ctx)
+	if !ok {
+		return
+	}
+	err := h.svc.ReleaseHold(ctx, principal.UserID, ctx.Param("hold_id"))
+	if errors.Is(err, seathold.ErrHoldNotFound) {
+		h.responseWithError(ctx, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		h.responseWithError(ctx, http.StatusBadGateway, err)
+		return
+	}
+	h.response(ctx, http.StatusOK, "seat hold released")
+}
+
+// streamSeats sends seat state changes of a showtime as server-sent events.
+// A client reconnecting with Last-Event-ID (or ?last_event_id=) gets the
+// events it missed; otherwise the stream starts with a snapshot of the
+// available seats of ?screen_id=.
+func (h *Handler) streamSeats(ctx *gin.Context) {
+	showtimeId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		h.responseWithError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	lastEventId := ctx.GetHeader("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = ctx.Query("last_event_id")
+	}
+	sub, replay, resumed, seq := h.seats.Subscribe(showtimeId, lastEventId)
+	defer h.seats.Unsubscribe(sub)
+
+	var snapshot *SeatSnapshot
+	if !resumed {
+		screenId, err := strconv.Atoi(ctx.Query("screen_id"))
+		if err != nil {
+			h.responseWithError(ctx, http.StatusBadRequest, ErrScreenRequired)
+			return
+		}
+		seats, err := h.svc.ListAvailableSeatsbyScreenIDAndShowTimeID(ctx, screenId, showtimeId)
+		if err != nil {
+			h.responseWithError(ctx, http.StatusBadGateway, err)
+			return
+		}
+		snapshot = &SeatSnapshot{ShowtimeID: showtimeId, Seq: seq, AvailableSeats: seats}
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	if snapshot != nil {
+		if err := h.writeEvent(ctx, h.seats.EventID(seq), "snapshot", snapshot); err != nil {
+			return
+		}
+	}
+	for _, event := range replay {
+		if err := h.writeEvent(ctx, h.seats.EventID(event.Seq), "seat", event); err != nil {
+			return
+		}
+	}
+
+	keepAlive := time.NewTicker(15 * time.Second)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case event, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+			if err := h.writeEvent(ctx, h.seats.EventID(event.Seq), "seat", event); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := ctx.Writer.WriteString(": keep-alive\n\n"); err != nil {
+				return
+			}
+			ctx.Writer.Flush()
+		case <-ctx.Request.Context().Done():
+			return
+		case <-h.closing:
+			return
+		}
+	}
+}
+
+// StartHoldExpiry releases expired seat holds every interval until the
+// returned stop function is called.
+func (h *Handler) StartHoldExpiry(interval time.Duration) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.svc.ExpireHolds(ctx)
+			}
+		}
+	}()
+	return cancel
+}
+
 // Booking
 func (h *Handler) listBookingsByUser(ctx *gin.Context) {
 	idstr := ctx.Param("user_id")
 	userId, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	page, err := pagination.FromQuery(ctx)
+	if err != nil {
+		h.responseWithError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	filter := BookingFilter{}
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		h.responseWithError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if err := ValidateBookingFilter(&filter); err != nil {
+		h.responseWithError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	bookings, err := h.svc.ListBookingsByUser(ctx, userId, filter, page)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
-	bookings, err := h.svc.ListBookingsByUser(ctx, userId)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get booking details succesfull", bookings)
@@ -206,37 +602,111 @@ func (h *Handler) getBookingByID(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	bookings, err := h.svc.GetBookingByID(ctx, id)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get booking details succesfull", bookings)
 
 }
-func (h *Handler) createBooking(ctx *gin.Context) {
-	authorization := ctx.Request.Header.Get("Authorization")
-	userId, err := h.svc.GetUserIDFromToken(ctx, authorization)
+
+// getTicket renders the e-ticket as a PDF, or as a PNG with ?format=png.
+func (h *Handler) getTicket(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		h.responseWithError(ctx, http.StatusUnauthorized, errors.New("unauthorized: Invalid token or user ID extraction failed"))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
+	format := ctx.DefaultQuery("format", "pdf")
+	if format != "pdf" && format != "png" {
+		h.responseWithError(ctx, http.StatusBadRequest, errors.New("format must be pdf or png"))
+		return
+	}
+	t, token, err := h.svc.Ticket(ctx, id)
+	if errors.Is(err, ErrTicketUnpaid) || errors.Is(err, ErrBookingCancelled) {
+		h.responseWithError(ctx, http.StatusConflict, err)
+		return
+	}
+	if errors.Is(err, ticket.ErrNoSigningKey) {
+		h.responseWithError(ctx, http.StatusServiceUnavailable, err)
+		return
+	}
+	if err != nil {
+		h.responseWithError(ctx, http.StatusBadGateway, err)
+		return
+	}
+
+	var data []byte
+	contentType := "application/pdf"
+	if format == "png" {
+		contentType = "image/png"
+		data, err = ticket.RenderPNG(t, token)
+	} else {
+		data, err = ticket.RenderPDF(t, token)
+	}
+	if err != nil {
+		h.responseWithError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="ticket-%s.%s"`, t.Reference, format))
+	ctx.Header("Cache-Control", "private, no-store")
+	ctx.Data(http.StatusOK, contentType, data)
+}
+
+func (h *Handler) cancelBooking(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		h.responseWithError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	cancelled, err := h.svc.CancelBooking(ctx, id)
+	if errors.Is(err, ErrBookingCancelled) || errors.Is(err, cancellation.ErrWindowClosed) {
+		h.responseWithError(ctx, http.StatusConflict, err)
+		return
+	}
+	if errors.Is(err, ErrRefundUnavailable) {
+		h.responseWithError(ctx, http.StatusServiceUnavailable, err)
+		return
+	}
+	if err != nil {
+		h.responseWithError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	audit.Record(ctx, "booking.cancelled", map[string]string{
+		"booking_id":    strconv.Itoa(id),
+		"refund_amount": strconv.FormatFloat(cancelled.RefundAmount, 'f', 2, 64),
+		"refund_status": cancelled.RefundStatus,
+	})
+	h.responseWithData(ctx, http.StatusOK, "booking cancelled", cancelled)
+}
+
+func (h *Handler) createBooking(ctx *gin.Context) {
+	principal, ok := common.RequirePrincipal(ctx)
+	if !ok {
+		return
+	}
+	userId := principal.UserID
 	bookingReq := &CreateBookingRequest{}
 	if err := ctx.ShouldBindJSON(bookingReq); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	bookingReq.UserID = userId
 	booking, err := h.svc.CreateBooking(ctx, *bookingReq)
+	if errors.Is(err, seathold.ErrHoldNotFound) {
+		h.responseWithError(ctx, http.StatusNotFound, err)
+		return
+	}
+	if errors.Is(err, ErrHoldUsed) {
+		h.responseWithError(ctx, http.StatusConflict, err)
+		return
+	}
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{
@@ -249,18 +719,15 @@ func (h *Handler) createBooking(ctx *gin.Context) {
 func (h *Handler) register(ctx *gin.Context) {
 	userData := User{}
 	if err := ctx.BindJSON(&userData); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	if err := ValidateUser(userData); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	if err := h.svc.Register(ctx.Request.Context(), &userData); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "signup successfull", map[string]string{
@@ -271,13 +738,11 @@ func (h *Handler) register(ctx *gin.Context) {
 func (h *Handler) registerValidate(ctx *gin.Context) {
 	userData := User{}
 	if err := ctx.ShouldBindJSON(&userData); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	if err := h.svc.RegisterValidate(ctx, &userData); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "register validate successfull")
@@ -286,29 +751,32 @@ func (h *Handler) registerValidate(ctx *gin.Context) {
 func (h *Handler) logIn(ctx *gin.Context) {
 	userData := User{}
 	if err := ctx.ShouldBindJSON(&userData); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	attempt, blocked := h.logins.Begin(userData.Email, ctx.ClientIP())
+	if blocked != nil {
+		lockout.Respond(ctx, blocked)
 		return
 	}
 	token, err := h.svc.Login(ctx, &userData)
+	attempt.End(err)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "login succesfull", token)
 }
 
 func (h *Handler) getProfile(ctx *gin.Context) {
-	authorization := ctx.Request.Header.Get("Authorization")
-	userId, err := h.svc.GetUserIDFromToken(ctx, authorization)
-	if err != nil {
-		h.responseWithError(ctx, http.StatusUnauthorized, errors.New("unauthorized: Invalid token or user ID extraction failed"))
+	principal, ok := common.RequirePrincipal(ctx)
+	if !ok {
 		return
 	}
+	userId := principal.UserID
 	profileDetails, err := h.svc.GetProfile(ctx, userId)
 	if err != nil {
-		h.responseWithError(ctx, http.StatusNotFound, errors.New("profile not found: Unable to retrieve profile details for the user"))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 
@@ -319,20 +787,17 @@ func (h *Handler) updateUserProfile(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	user := &UserProfileDetails{}
 	if err := ctx.ShouldBindJSON(&user); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err = h.svc.UpdateUserProfile(ctx, id, *user)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "update user profile successfull")
@@ -341,14 +806,12 @@ func (h *Handler) updateUserProfile(ctx *gin.Context) {
 func (h *Handler) forgotPassword(ctx *gin.Context) {
 	email := ForgotPassword{}
 	if err := ctx.ShouldBindJSON(&email); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err := h.svc.ForgotPassword(ctx, email.Email)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "otp send successfull")
@@ -357,14 +820,12 @@ func (h *Handler) forgotPassword(ctx *gin.Context) {
 func (h *Handler) resetPassword(ctx *gin.Context) {
 	data := ResetPassword{}
 	if err := ctx.ShouldBindJSON(&data); err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusBadRequest, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	err := h.svc.ResetPassword(ctx, data)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.response(ctx, http.StatusOK, "password reset successfull")
@@ -374,8 +835,7 @@ func (h *Handler) resetPassword(ctx *gin.Context) {
 func (h *Handler) listAllMovies(ctx *gin.Context) {
 	movies, err := h.svc.ListAllMovies(ctx)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "list all movies successfully", movies)
@@ -385,14 +845,12 @@ func (h *Handler) getMovieDetailsByID(ctx *gin.Context) {
 	movieIDstr := ctx.Param("id")
 	movieID, err := strconv.Atoi(movieIDstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	movie, err := h.svc.GetMovieDetailsByID(ctx, movieID)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get movie details successfully", movie)
@@ -402,8 +860,7 @@ func (h *Handler) getMovieByName(ctx *gin.Context) {
 	movieName := ctx.Query("name")
 	movie, err := h.svc.GetMovieByName(ctx, movieName)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get movie by name successfully", movie)
@@ -413,8 +870,7 @@ func (h *Handler) getMoviesByGenre(ctx *gin.Context) {
 	genre := ctx.Query("genre")
 	movies, err := h.svc.GetMoviesByGenre(ctx, genre)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get movies by genre successfully", movies)
@@ -424,8 +880,7 @@ func (h *Handler) getMoviesByLanguage(ctx *gin.Context) {
 	language := ctx.Query("language")
 	movies, err := h.svc.GetMoviesByLanguage(ctx, language)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get movies by language successfully", movies)
@@ -437,8 +892,7 @@ func (h *Handler) getMovieByNameAndLanguage(ctx *gin.Context) {
 
 	movie, err := h.svc.GetMovieByNameAndLanguage(ctx, name, language)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 
@@ -449,8 +903,7 @@ func (h *Handler) getMovieByNameAndLanguage(ctx *gin.Context) {
 func (h *Handler) listAllTheaters(ctx *gin.Context) {
 	theaters, err := h.svc.ListAllTheaters(ctx)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "list all theaters successfully", theaters)
@@ -460,14 +913,12 @@ func (h *Handler) getTheaterByID(ctx *gin.Context) {
 	theaterIDstr := ctx.Param("id")
 	theaterID, err := strconv.Atoi(theaterIDstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	theater, err := h.svc.GetTheaterByID(ctx, theaterID)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get theater by ID successfully", theater)
@@ -477,8 +928,7 @@ func (h *Handler) getTheatersByCity(ctx *gin.Context) {
 	city := ctx.Query("city")
 	theaters, err := h.svc.GetTheatersByCity(ctx, city)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get theaters by city successfully", theaters)
@@ -488,8 +938,7 @@ func (h *Handler) getTheatersByName(ctx *gin.Context) {
 	theaterName := ctx.Query("name")
 	theaters, err := h.svc.GetTheatersByName(ctx, theaterName)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get theaters by name successfully", theaters)
@@ -499,8 +948,7 @@ func (h *Handler) getTheatersAndMovieScheduleByMovieName(ctx *gin.Context) {
 	movieName := ctx.Query("movie_name")
 	theaters, err := h.svc.GetTheatersAndMovieScheduleByMovieName(ctx, movieName)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get theaters by movie name successfully", theaters)
@@ -510,14 +958,12 @@ func (h *Handler) getScreensAndMovieScedulesByTheaterID(ctx *gin.Context) {
 	theaterIDstr := ctx.Param("id")
 	theaterID, err := strconv.Atoi(theaterIDstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	screens, err := h.svc.GetScreensAndMovieSchedulesByTheaterID(ctx, theaterID)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "get screens and movie schedules by theater ID successfully", screens)
@@ -527,14 +973,12 @@ func (h *Handler) listShowTimeByTheaterID(ctx *gin.Context) {
 	theaterIDstr := ctx.Param("id")
 	theaterID, err := strconv.Atoi(theaterIDstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	showtimes, err := h.svc.ListShowTimeByTheaterID(ctx, theaterID)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "list showtimes by theater ID successfully", showtimes)
@@ -545,20 +989,17 @@ func (h *Handler) listShowTimeByTheaterIDandMovieID(ctx *gin.Context) {
 	movieIDstr := ctx.Param("movie_id")
 	theaterID, err := strconv.Atoi(theaterIDstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	movieID, err := strconv.Atoi(movieIDstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	showtimes, err := h.svc.ListShowTimeByTheaterIDandMovieID(ctx, theaterID, movieID)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "list showtimes by theater ID and movie ID successfully", showtimes)
@@ -568,14 +1009,12 @@ func (h *Handler) listSeatsbyScreenID(ctx *gin.Context) {
 	idstr := ctx.Param("screen_id")
 	screenId, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	seats, err := h.svc.ListSeatsbyScreenID(ctx, screenId)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "list seats by screen id successfully", seats)
@@ -586,21 +1025,18 @@ func (h *Handler) listAvailableSeatsbyScreenIDAndShowTimeID(ctx *gin.Context) {
 	screenidstr := ctx.Param("screen_id")
 	screenId, err := strconv.Atoi(screenidstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	showtimeidstr := ctx.Param("showtime_id")
 	showtimeId, err := strconv.Atoi(showtimeidstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	seats, err := h.svc.ListAvailableSeatsbyScreenIDAndShowTimeID(ctx, screenId, showtimeId)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "list seats by screen id successfully", seats)
@@ -610,14 +1046,12 @@ func (h *Handler) getSeatBySeatID(ctx *gin.Context) {
 	idstr := ctx.Param("seat_id")
 	seatId, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	seat, err := h.svc.GetSeatBySeatID(ctx, seatId)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "list seats by seat id successfully", seat)
@@ -627,16 +1061,14 @@ func (h *Handler) listShowtimeByMovieIdAndShowDate(ctx *gin.Context) {
 	idstr := ctx.Param("movie_id")
 	movieId, err := strconv.Atoi(idstr)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusInternalServerError, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadRequest, err)
 		return
 	}
 	showDateStr := ctx.DefaultQuery("show_date", "")
 	showDate, _ := time.Parse(time.RFC3339, showDateStr)
 	showtimes, err := h.svc.ListShowtimeByMovieIdAndShowDate(ctx, showDate, movieId)
 	if err != nil {
-		formattedError := ExtractErrorMessage(err)
-		h.responseWithError(ctx, http.StatusNotFound, errors.New(formattedError))
+		h.responseWithError(ctx, http.StatusBadGateway, err)
 		return
 	}
 	h.responseWithData(ctx, http.StatusOK, "list showtimes by movie_id and show date successfully", showtimes)
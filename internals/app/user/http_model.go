@@ -208,17 +208,60 @@ type Booking struct {
 	BookingSeats  []BookingSeat `json:"booking_seats"`
 }
 
+// BookingFilter narrows and orders a user's booking list. From and To are
+// dates (2006-01-02) bounding the booking date, both inclusive.
+type BookingFilter struct {
+	When          string `form:"when"`
+	PaymentStatus string `form:"payment_status"`
+	From          string `form:"from"`
+	To            string `form:"to"`
+	TheaterID     int    `form:"theater_id"`
+	Sort          string `form:"sort"`
+	Order         string `form:"order"`
+
+	from, to time.Time
+}
+
+type Cancellation struct {
+	BookingID    uint      `json:"booking_id"`
+	Status       string    `json:"status"`
+	TotalAmount  float64   `json:"total_amount"`
+	Fee          float64   `json:"cancellation_fee"`
+	RefundAmount float64   `json:"refund_amount"`
+	RefundStatus string    `json:"refund_status"`
+	CancelledAt  time.Time `json:"cancelled_at"`
+}
+
 type BookingSeat struct {
 	BookingID uint `json:"booking_id"`
 	SeatID    uint `json:"seat_id"`
 }
 
 type CreateBookingRequest struct {
-	UserID      int      `json:"user_id"`
-	ShowtimeID  int      `json:"showtime_id"`
-	ScreenID    uint     `json:"screen_id"`
-	SeatIDs     []uint32 `json:"seat_ids"`
-	TotalAmount float64  `json:"total_amount"`
+	UserID     int      `json:"user_id"`
+	ShowtimeID int      `json:"showtime_id"`
+	ScreenID   uint     `json:"screen_id"`
+	SeatIDs    []uint32 `json:"seat_ids"`
+	// TotalAmount is what the client displayed. It is optional and only
+	// checked against the price computed from the seats.
+	TotalAmount float64 `json:"total_amount"`
+	HoldID      string  `json:"hold_id"`
+}
+
+type SeatHoldRequest struct {
+	ScreenID int      `json:"screen_id"`
+	SeatIDs  []uint32 `json:"seat_ids"`
+}
+
+// SeatSnapshot is the first event of a seat stream that could not resume.
+type SeatSnapshot struct {
+	ShowtimeID     int                  `json:"showtime_id"`
+	Seq            uint64               `json:"seq"`
+	AvailableSeats []SeatsByScreenIDRes `json:"available_seats"`
+}
+
+type ProcessPaymentRequest struct {
+	PaymentMethodID uint `json:"payment_method_id"`
 }
 
 type PaymentMethod struct {
@@ -255,6 +298,19 @@ type TransactionResponse struct {
 type PaymentStatusRequest struct {
 	OrderID           string `json:"order_id"`
 	RazorpayPaymentID string `json:"payment_id"`
+	Signature         string `json:"razorpay_signature"`
+}
+
+// PaymentFailureRequest carries the error Razorpay Checkout reports in its
+// payment.failed callback.
+type PaymentFailureRequest struct {
+	OrderID          string `json:"order_id" validate:"required,max=64"`
+	PaymentID        string `json:"payment_id" validate:"required,max=64"`
+	ErrorCode        string `json:"error_code" validate:"required,max=64"`
+	ErrorDescription string `json:"error_description" validate:"max=512"`
+	Source           string `json:"source" validate:"max=64"`
+	Step             string `json:"step" validate:"max=64"`
+	Reason           string `json:"reason" validate:"max=128"`
 }
 
 type Message struct {
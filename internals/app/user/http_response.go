@@ -1,6 +1,10 @@
 package user
 
 import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aparnasukesh/api-gateway/pkg/common"
 	"github.com/gin-gonic/gin"
 )
 
@@ -17,8 +21,21 @@ func (h Handler) responseWithData(ctx *gin.Context, statusCode int, msg string,
 	})
 }
 
+// responseWithError maps gRPC status errors to their HTTP status and uses
+// statusCode only for errors that carry no usable gRPC code.
 func (h Handler) responseWithError(ctx *gin.Context, statusCode int, err error) {
-	ctx.JSON(statusCode, gin.H{
-		"error": err.Error(),
-	})
+	common.ResponseWithError(ctx, statusCode, err)
+}
+
+// writeEvent writes one server-sent event and flushes it to the client.
+func (h Handler) writeEvent(ctx *gin.Context, id, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(ctx.Writer, "id: %s\nevent: %s\ndata: %s\n\n", id, event, payload); err != nil {
+		return err
+	}
+	ctx.Writer.Flush()
+	return nil
 }
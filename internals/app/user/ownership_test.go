@@ -0,0 +1,118 @@
+package user
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aparnasukesh/api-gateway/pkg/common"
+	"github.com/aparnasukesh/api-gateway/pkg/transactions"
+	"github.com/aparnasukesh/inter-communication/movie_booking"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeBookingClient struct {
+	movie_booking.BookingServiceClient
+	bookings map[uint32]*movie_booking.Booking
+	lookups  int
+}
+
+func (f *fakeBookingClient) GetBookingByID(ctx context.Context, in *movie_booking.GetBookingByIDRequest, opts ...grpc.CallOption) (*movie_booking.GetBookingByIDResponse, error) {
+	f.lookups++
+	booking, ok := f.bookings[in.BookingId]
+	if !ok {
+		return nil, status.Error(codes.Unknown, "record not found")
+	}
+	return &movie_booking.GetBookingByIDResponse{Booking: booking}, nil
+}
+
+func newOwnershipRouter(principal *common.Principal, param string, resolve common.OwnerResolver) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(ctx *gin.Context) {
+		if principal != nil {
+			common.SetPrincipal(ctx, principal)
+		}
+	})
+	r.GET("/resource/:"+param, common.RequireOwnership(param, resolve), func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+	return r
+}
+
+func TestBookingOwnership(t *testing.T) {
+	tests := []struct {
+		name        string
+		principal   *common.Principal
+		path        string
+		wantStatus  int
+		wantLookups int
+	}{
+		{"owner", &common.Principal{UserID: 7, Role: common.RoleUser}, "/resource/1", http.StatusOK, 1},
+		{"other user", &common.Principal{UserID: 8, Role: common.RoleUser}, "/resource/1", http.StatusForbidden, 1},
+		{"admin", &common.Principal{UserID: 1, Role: common.RoleAdmin}, "/resource/1", http.StatusOK, 0},
+		{"super admin", &common.Principal{UserID: 1, Role: common.RoleSuperAdmin}, "/resource/1", http.StatusOK, 0},
+		{"missing booking", &common.Principal{UserID: 7, Role: common.RoleUser}, "/resource/99", http.StatusNotFound, 1},
+		{"malformed id", &common.Principal{UserID: 7, Role: common.RoleUser}, "/resource/abc", http.StatusBadRequest, 0},
+		{"anonymous", nil, "/resource/1", http.StatusUnauthorized, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bookings := &fakeBookingClient{bookings: map[uint32]*movie_booking.Booking{
+				1: {BookingId: 1, UserId: 7},
+			}}
+			svc := &service{bookingClient: bookings}
+			r := newOwnershipRouter(tt.principal, "booking_id", svc.BookingOwner)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if bookings.lookups != tt.wantLookups {
+				t.Errorf("booking lookups = %d, want %d", bookings.lookups, tt.wantLookups)
+			}
+		})
+	}
+}
+
+func TestTransactionOwnership(t *testing.T) {
+	tests := []struct {
+		name       string
+		principal  *common.Principal
+		path       string
+		wantStatus int
+	}{
+		{"owner of the booking", &common.Principal{UserID: 7, Role: common.RoleUser}, "/resource/100", http.StatusOK},
+		{"other user", &common.Principal{UserID: 8, Role: common.RoleUser}, "/resource/100", http.StatusForbidden},
+		{"unknown transaction", &common.Principal{UserID: 7, Role: common.RoleUser}, "/resource/404", http.StatusForbidden},
+		{"booking gone", &common.Principal{UserID: 7, Role: common.RoleUser}, "/resource/200", http.StatusNotFound},
+		{"admin", &common.Principal{UserID: 1, Role: common.RoleAdmin}, "/resource/404", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := transactions.NewMemoryStore()
+			store.Put(ctx, 100, 1, time.Hour)
+			store.Put(ctx, 200, 2, time.Hour)
+			svc := &service{
+				bookingClient: &fakeBookingClient{bookings: map[uint32]*movie_booking.Booking{
+					1: {BookingId: 1, UserId: 7},
+				}},
+				transactions: store,
+			}
+			r := newOwnershipRouter(tt.principal, "transaction_id", svc.TransactionOwner)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aparnasukesh/api-gateway/pkg/rabbitmq"
+	"github.com/aparnasukesh/api-gateway/pkg/seathold"
+	"github.com/aparnasukesh/api-gateway/pkg/transactions"
+	"github.com/aparnasukesh/inter-communication/movie_booking"
+	"github.com/aparnasukesh/inter-communication/payment"
+	"google.golang.org/grpc"
+)
+
+type fakePaymentClient struct {
+	payment.PaymentServiceClient
+	failures []*payment.PaymentFailureRequest
+	charges  []*payment.ProcessPaymentRequest
+}
+
+func (f *fakePaymentClient) ProcessPayment(ctx context.Context, in *payment.ProcessPaymentRequest, opts ...grpc.CallOption) (*payment.ProcessPaymentResponse, error) {
+	f.charges = append(f.charges, in)
+	return &payment.ProcessPaymentResponse{Transaction: &payment.Transaction{
+		TransactionId: 100,
+		BookingId:     in.BookingId,
+		UserId:        in.UserId,
+		Amount:        in.Amount,
+		OrderId:       "order_1",
+		Status:        "pending",
+	}}, nil
+}
+
+func (f *fakePaymentClient) PaymentFailure(ctx context.Context, in *payment.PaymentFailureRequest, opts ...grpc.CallOption) (*payment.PaymentFailureResponse, error) {
+	f.failures = append(f.failures, in)
+	return &payment.PaymentFailureResponse{}, nil
+}
+
+func newTestPublisher(t *testing.T) *rabbitmq.Publisher {
+	t.Helper()
+	outbox, err := rabbitmq.OpenOutbox(filepath.Join(t.TempDir(), "events.outbox"), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := rabbitmq.NewPublisher(rabbitmq.PublisherConfig{}, outbox)
+	t.Cleanup(events.Close)
+	return events
+}
+
+func TestPaymentFailureOwnership(t *testing.T) {
+	tests := []struct {
+		name         string
+		userID       int
+		orderID      string
+		wantErr      error
+		wantForwards int
+	}{
+		{name: "holder", userID: 7, orderID: "order_1", wantForwards: 1},
+		{name: "other user", userID: 8, orderID: "order_1", wantErr: ErrOrderNotFound},
+		{name: "unknown order", userID: 7, orderID: "order_2", wantErr: ErrOrderNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			holds := seathold.NewMemoryStore()
+			holds.Create(ctx, &seathold.Hold{
+				ID:         "hold_1",
+				UserID:     7,
+				ShowtimeID: 3,
+				SeatIDs:    []uint32{1, 2},
+				BookingID:  1,
+				OrderID:    "order_1",
+				ExpiresAt:  time.Now().Add(time.Hour),
+			})
+			payments := &fakePaymentClient{}
+			svc := &service{
+				bookingClient: &fakeBookingClient{bookings: map[uint32]*movie_booking.Booking{
+					1: {BookingId: 1, UserId: 7, PaymentStatus: "paid"},
+				}},
+				paymentClient: payments,
+				holds:         holds,
+				events:        newTestPublisher(t),
+			}
+
+			err := svc.PaymentFailure(ctx, tt.userID, PaymentFailureRequest{OrderID: tt.orderID, PaymentID: "pay_1", ErrorCode: "BAD_REQUEST_ERROR"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if len(payments.failures) != tt.wantForwards {
+				t.Fatalf("forwarded %d failures, want %d", len(payments.failures), tt.wantForwards)
+			}
+			if tt.wantForwards > 0 && payments.failures[0].BookingId != 1 {
+				t.Errorf("forwarded booking %d, want 1", payments.failures[0].BookingId)
+			}
+			_, err = holds.Get(ctx, "hold_1")
+			if released := errors.Is(err, seathold.ErrHoldNotFound); released != (tt.wantErr == nil) {
+				t.Errorf("hold released = %v, want %v", released, tt.wantErr == nil)
+			}
+		})
+	}
+}
+
+func TestProcessPaymentStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      string
+		amount      float64
+		wantErr     error
+		wantCharges int
+	}{
+		{name: "pending", status: "pending", amount: 300, wantCharges: 1},
+		{name: "failed before", status: "failed", amount: 300, wantCharges: 1},
+		{name: "already paid", status: "paid", amount: 300, wantErr: ErrBookingPaid},
+		{name: "cancelled", status: "cancelled", amount: 300, wantErr: ErrBookingCancelled},
+		{name: "unpriced", status: "pending", amount: 0, wantErr: ErrUnpricedBooking},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payments := &fakePaymentClient{}
+			svc := &service{
+				bookingClient: &fakeBookingClient{bookings: map[uint32]*movie_booking.Booking{
+					1: {BookingId: 1, UserId: 7, PaymentStatus: tt.status, TotalAmount: tt.amount},
+				}},
+				paymentClient: payments,
+				holds:         seathold.NewMemoryStore(),
+				transactions:  transactions.NewMemoryStore(),
+			}
+
+			_, err := svc.ProcessPayment(context.Background(), 1, 7, 1)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if len(payments.charges) != tt.wantCharges {
+				t.Fatalf("charged %d times, want %d", len(payments.charges), tt.wantCharges)
+			}
+		})
+	}
+}
@@ -3,22 +3,65 @@ package user
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/aparnasukesh/inter-communication/auth"
+	"github.com/aparnasukesh/api-gateway/pkg/cancellation"
+	"github.com/aparnasukesh/api-gateway/pkg/common"
+	"github.com/aparnasukesh/api-gateway/pkg/pagination"
+	"github.com/aparnasukesh/api-gateway/pkg/rabbitmq"
+	"github.com/aparnasukesh/api-gateway/pkg/razorpay"
+	"github.com/aparnasukesh/api-gateway/pkg/seathold"
+	"github.com/aparnasukesh/api-gateway/pkg/seatstream"
+	"github.com/aparnasukesh/api-gateway/pkg/ticket"
+	"github.com/aparnasukesh/api-gateway/pkg/transactions"
+
 	"github.com/aparnasukesh/inter-communication/movie_booking"
 	"github.com/aparnasukesh/inter-communication/payment"
 	"github.com/aparnasukesh/inter-communication/user_admin"
-	"github.com/streadway/amqp"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+var (
+	ErrNoSeats          = errors.New("at least one seat is required")
+	ErrDuplicateSeat    = errors.New("seat selected more than once")
+	ErrSeatNotOnScreen  = errors.New("seat does not belong to the selected screen")
+	ErrAmountMismatch   = errors.New("total amount does not match the seat prices")
+	ErrUnpricedBooking  = errors.New("booking has no amount to charge")
+	ErrScreenRequired   = errors.New("screen_id is required")
+	ErrSeatUnavailable  = errors.New("seat is not available for this showtime")
+	ErrHoldRequired     = errors.New("hold_id is required")
+	ErrHoldMismatch     = errors.New("booking does not match the seat hold")
+	ErrHoldUsed         = errors.New("seat hold already has a booking")
+	ErrBookingCancelled = errors.New("booking is already cancelled")
+	ErrBookingPaid      = errors.New("booking is already paid")
+	ErrTicketUnpaid     = errors.New("tickets are issued once the booking is paid")
+	ErrTicketDetails    = errors.New("upstream returned no showtime, movie or theater for the ticket")
+	ErrOrderNotFound    = errors.New("order not found")
+	// ErrRefundUnavailable is returned when a paid booking cannot be
+	// cancelled because its refund could not be recorded.
+	ErrRefundUnavailable = errors.New("refunds cannot be recorded right now, the booking was not cancelled")
+)
+
+const bookingStatusCancelled = "cancelled"
+
+// Refund states reported when a booking is cancelled.
+const (
+	RefundNotRequired = "not_required"
+	RefundPending     = "pending"
+)
+
 type Service interface {
 	Register(ctx context.Context, signUpData *User) error
 	RegisterValidate(ctx context.Context, userData *User) error
 	Login(ctx context.Context, loginData *User) (string, error)
-	GetUserIDFromToken(ctx context.Context, authorization string) (int, error)
 	GetProfile(ctx context.Context, userId int) (*UserProfileDetails, error)
 	UpdateUserProfile(ctx context.Context, id int, user UserProfileDetails) error
 	ForgotPassword(ctx context.Context, email string) error
@@ -44,81 +87,85 @@ type Service interface {
 	ListSeatsbyScreenID(ctx context.Context, screenId int) ([]SeatsByScreenIDRes, error)
 	ListAvailableSeatsbyScreenIDAndShowTimeID(ctx context.Context, screenId, showtimeId int) ([]SeatsByScreenIDRes, error)
 	GetSeatBySeatID(ctx context.Context, seatId int) (*SeatsByScreenIDRes, error)
+	// Seat holds
+	HoldSeats(ctx context.Context, userId int, showtimeId int, req SeatHoldRequest) (*seathold.Hold, error)
+	ReleaseHold(ctx context.Context, userId int, holdId string) error
+	ExpireHolds(ctx context.Context)
 	// Booking
 	CreateBooking(ctx context.Context, bookingReq CreateBookingRequest) (*Booking, error)
 	GetBookingByID(ctx context.Context, id int) (*Booking, error)
-	ListBookingsByUser(ctx context.Context, userId int) ([]Booking, error)
+	ListBookingsByUser(ctx context.Context, userId int, filter BookingFilter, page pagination.Request) (*pagination.Page[Booking], error)
+	BookingOwner(ctx context.Context, id int) (int, error)
+	CancelBooking(ctx context.Context, id int) (*Cancellation, error)
+	Ticket(ctx context.Context, bookingId int) (*ticket.Ticket, string, error)
 	// Payment
 	GetTransactionStatus(ctx context.Context, id int) (*TransactionResponse, error)
-	ProcessPayment(ctx context.Context, bookingId int, userId int) (*Transaction, error)
+	ProcessPayment(ctx context.Context, bookingId int, userId int, paymentMethodId int) (*Transaction, error)
 	PaymentSuccess(ctx context.Context, req PaymentStatusRequest) error
-	PaymentFailure(ctx context.Context, req PaymentStatusRequest) error
+	PaymentFailure(ctx context.Context, userId int, req PaymentFailureRequest) error
+	TransactionOwner(ctx context.Context, id int) (int, error)
 	// Chat
 	HelpDeskChat(ctx context.Context, message []byte, userId int) ([]byte, error)
 }
 
 type service struct {
-	userAdmin          user_admin.UserServiceClient
-	auth               auth.JWT_TokenServiceClient
-	movieBooking       movie_booking.MovieServiceClient
-	theaterClient      movie_booking.TheatreServiceClient
-	bookingClient      movie_booking.BookingServiceClient
-	paymentClient      payment.PaymentServiceClient
-	rabbitmqConnection *amqp.Connection
+	userAdmin         user_admin.UserServiceClient
+	movieBooking      movie_booking.MovieServiceClient
+	theaterClient     movie_booking.TheatreServiceClient
+	bookingClient     movie_booking.BookingServiceClient
+	paymentClient     payment.PaymentServiceClient
+	razorpayKeySecret string
+	holds             seathold.Store
+	holdTTL           time.Duration
+	paymentHoldTTL    time.Duration
+	seats             *seatstream.Hub
+	cancellations     *cancellation.Policy
+	tickets           *ticket.Signer
+	helpDesk          *rabbitmq.RPCClient
+	events            *rabbitmq.Publisher
+	transactions      transactions.Store
+	transactionTTL    time.Duration
 }
 
-func NewService(pb user_admin.UserServiceClient, auth auth.JWT_TokenServiceClient, movieBooking movie_booking.MovieServiceClient, theaterClient movie_booking.TheatreServiceClient, bookingClient movie_booking.BookingServiceClient, paymentClient payment.PaymentServiceClient, rabbitmqConnection *amqp.Connection) Service {
+func NewService(pb user_admin.UserServiceClient, movieBooking movie_booking.MovieServiceClient, theaterClient movie_booking.TheatreServiceClient, bookingClient movie_booking.BookingServiceClient, paymentClient payment.PaymentServiceClient, razorpayKeySecret string, holds seathold.Store, holdTTL time.Duration, paymentHoldTTL time.Duration, seats *seatstream.Hub, cancellations *cancellation.Policy, tickets *ticket.Signer, helpDesk *rabbitmq.RPCClient, events *rabbitmq.Publisher, transactionStore transactions.Store, transactionTTL time.Duration) Service {
 	return &service{
-		userAdmin:          pb,
-		auth:               auth,
-		movieBooking:       movieBooking,
-		theaterClient:      theaterClient,
-		bookingClient:      bookingClient,
-		paymentClient:      paymentClient,
-		rabbitmqConnection: rabbitmqConnection,
+		userAdmin:         pb,
+		movieBooking:      movieBooking,
+		theaterClient:     theaterClient,
+		bookingClient:     bookingClient,
+		paymentClient:     paymentClient,
+		razorpayKeySecret: razorpayKeySecret,
+		holds:             holds,
+		holdTTL:           holdTTL,
+		paymentHoldTTL:    paymentHoldTTL,
+		seats:             seats,
+		cancellations:     cancellations,
+		tickets:           tickets,
+		helpDesk:          helpDesk,
+		events:            events,
+		transactions:      transactionStore,
+		transactionTTL:    transactionTTL,
 	}
 }
 
 // Chat
 func (s *service) HelpDeskChat(ctx context.Context, message []byte, userId int) ([]byte, error) {
-	queue, err := RabbitMQQueue(s.rabbitmqConnection, "chat_queue")
-	if err != nil {
-		return nil, err
-	}
-
-	correlationID := randomString(32)
-
-	replyQueue, err := setupReplyQueue(queue)
-	if err != nil {
-		return nil, err
-	}
-
-	defer queue.ch.QueueDelete(replyQueue.Name, false, false, false)
-
-	msgInput := Message{
+	b, err := json.Marshal(Message{
 		UserID:  userId,
 		Message: string(message),
 		SentAt:  time.Now(),
-	}
-
-	b, err := json.Marshal(msgInput)
-	if err != nil {
-		return nil, err
-	}
-
-	if err := sendMessage(correlationID, queue, b, replyQueue); err != nil {
-		return nil, err
-	}
-
-	msg, err := waitForResponse(queue, replyQueue, correlationID)
+	})
 	if err != nil {
 		return nil, err
 	}
-	return msg.Body, nil
+	return s.helpDesk.Call(ctx, b, "text/plain")
 }
 
 // Payment
 func (s *service) PaymentSuccess(ctx context.Context, req PaymentStatusRequest) error {
+	if err := razorpay.VerifyPaymentSignature(s.razorpayKeySecret, req.OrderID, req.RazorpayPaymentID, req.Signature); err != nil {
+		return err
+	}
 	_, err := s.paymentClient.PaymentSuccess(ctx, &payment.PaymentSuccessRequest{
 		OrderId:           req.OrderID,
 		RazorpayPaymentId: req.RazorpayPaymentID,
@@ -126,30 +173,96 @@ func (s *service) PaymentSuccess(ctx context.Context, req PaymentStatusRequest)
 	if err != nil {
 		return err
 	}
+	event := rabbitmq.PaymentSucceeded{
+		OrderID:   req.OrderID,
+		PaymentID: req.RazorpayPaymentID,
+		Source:    rabbitmq.SourceCheckout,
+	}
+	if hold, err := s.holds.FindByOrder(ctx, req.OrderID); err == nil {
+		s.holds.Delete(ctx, hold.ID)
+		s.seats.Publish(hold.ShowtimeID, hold.SeatIDs, seatstream.StateBooked)
+		event.BookingID = hold.BookingID
+	}
+	s.events.Publish(event)
 	return nil
 }
 
-func (s *service) PaymentFailure(ctx context.Context, req PaymentStatusRequest) error {
-	_, err := s.paymentClient.PaymentFailure(ctx, &payment.PaymentFailureRequest{
+// PaymentFailure forwards the failure to payment-svc. PaymentFailureRequest
+// in the proto has no fields for the error details, so they travel as
+// metadata and are logged here. Unlike a success, a failure carries no
+// signature, so it is only accepted for an order held by the caller.
+func (s *service) PaymentFailure(ctx context.Context, userId int, req PaymentFailureRequest) error {
+	hold, err := s.holds.FindByOrder(ctx, req.OrderID)
+	if err != nil || hold.UserID != userId {
+		return ErrOrderNotFound
+	}
+	log.Printf("payment failed: order %s, payment %s, code %s, reason %s, source %s, step %s: %s",
+		req.OrderID, req.PaymentID, req.ErrorCode, req.Reason, req.Source, req.Step, req.ErrorDescription)
+	ctx = metadata.AppendToOutgoingContext(ctx,
+		"x-payment-error-code", req.ErrorCode,
+		"x-payment-error-reason", req.Reason,
+		"x-payment-error-description", req.ErrorDescription,
+	)
+	_, err = s.paymentClient.PaymentFailure(ctx, &payment.PaymentFailureRequest{
 		OrderId:           req.OrderID,
-		RazorpayPaymentId: req.RazorpayPaymentID,
+		RazorpayPaymentId: req.PaymentID,
+		BookingId:         int32(hold.BookingID),
 	})
 	if err != nil {
 		return err
 	}
+	s.releaseHold(ctx, hold)
+	s.events.Publish(rabbitmq.PaymentFailed{
+		OrderID:   req.OrderID,
+		PaymentID: req.PaymentID,
+		BookingID: hold.BookingID,
+		ErrorCode: req.ErrorCode,
+		Reason:    req.Reason,
+		Source:    rabbitmq.SourceCheckout,
+	})
 	return nil
 }
 
-func (s *service) ProcessPayment(ctx context.Context, bookingId int, userId int) (*Transaction, error) {
+// ProcessPayment charges the amount stored on the booking, which
+// CreateBooking priced from the seats, rather than anything the client sends.
+// Only bookings still awaiting payment can be charged.
+func (s *service) ProcessPayment(ctx context.Context, bookingId int, userId int, paymentMethodId int) (*Transaction, error) {
+	booking, err := s.GetBookingByID(ctx, bookingId)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(booking.PaymentStatus, bookingStatusCancelled) {
+		return nil, ErrBookingCancelled
+	}
+	if !isUnpaid(booking.PaymentStatus) {
+		return nil, ErrBookingPaid
+	}
+	if booking.TotalAmount <= 0 {
+		return nil, ErrUnpricedBooking
+	}
 	res, err := s.paymentClient.ProcessPayment(ctx, &payment.ProcessPaymentRequest{
 		BookingId:       int32(bookingId),
 		UserId:          int32(userId),
-		Amount:          0,
-		PaymentMethodId: 1,
+		Amount:          booking.TotalAmount,
+		PaymentMethodId: int32(paymentMethodId),
 	})
 	if err != nil {
 		return nil, err
 	}
+	if err := s.transactions.Put(ctx, int(res.Transaction.TransactionId), bookingId, s.transactionTTL); err != nil {
+		log.Printf("failed to record booking %d for transaction %d: %v", bookingId, res.Transaction.TransactionId, err)
+	}
+	// Keep the seats through checkout so ExpireHolds does not free them
+	// while the payment is still going through.
+	if hold, err := s.holds.FindByBooking(ctx, bookingId); err == nil {
+		hold.OrderID = res.Transaction.OrderId
+		if until := time.Now().Add(s.paymentHoldTTL); until.After(hold.ExpiresAt) {
+			hold.ExpiresAt = until
+		}
+		if err := s.holds.Update(ctx, hold); err != nil {
+			log.Printf("failed to attach order %s to seat hold %s: %v", hold.OrderID, hold.ID, err)
+		}
+	}
 	return &Transaction{
 		TransactionID:   uint(res.Transaction.TransactionId),
 		BookingID:       uint(res.Transaction.BookingId),
@@ -162,6 +275,20 @@ func (s *service) ProcessPayment(ctx context.Context, bookingId int, userId int)
 	}, nil
 }
 
+// TransactionOwner is the owner of the booking the transaction pays for, as
+// booking-svc reports it. Transactions the store does not know are treated
+// as not owned.
+func (s *service) TransactionOwner(ctx context.Context, id int) (int, error) {
+	bookingId, err := s.transactions.BookingOf(ctx, id)
+	if errors.Is(err, transactions.ErrUnknownTransaction) {
+		return 0, common.ErrNotOwner
+	}
+	if err != nil {
+		return 0, err
+	}
+	return s.BookingOwner(ctx, bookingId)
+}
+
 func (s *service) GetTransactionStatus(ctx context.Context, id int) (*TransactionResponse, error) {
 	res, err := s.paymentClient.GetTransactionStatus(ctx, &payment.GetTransactionStatusRequest{
 		TransactionId: int32(id),
@@ -178,35 +305,517 @@ func (s *service) GetTransactionStatus(ctx context.Context, id int) (*Transactio
 	}, nil
 }
 
+// Seat holds
+func (s *service) HoldSeats(ctx context.Context, userId int, showtimeId int, req SeatHoldRequest) (*seathold.Hold, error) {
+	if req.ScreenID == 0 {
+		return nil, ErrScreenRequired
+	}
+	if len(req.SeatIDs) == 0 {
+		return nil, ErrNoSeats
+	}
+	response, err := s.theaterClient.GetAvailableSeatsByScreenIDAndShowTimeID(ctx, &movie_booking.GetAvailableSeatsByScreenIDAndShowTimeIDRequest{
+		ScreenId:   int32(req.ScreenID),
+		ShowtimeId: int32(showtimeId),
+	})
+	if err != nil {
+		return nil, err
+	}
+	available := make(map[uint32]bool, len(response.Seats))
+	for _, seat := range response.Seats {
+		available[uint32(seat.Id)] = true
+	}
+	seen := make(map[uint32]bool, len(req.SeatIDs))
+	for _, seatId := range req.SeatIDs {
+		if seen[seatId] {
+			return nil, fmt.Errorf("%w: %d", ErrDuplicateSeat, seatId)
+		}
+		seen[seatId] = true
+		if !available[seatId] {
+			return nil, fmt.Errorf("%w: %d", ErrSeatUnavailable, seatId)
+		}
+	}
+	hold := &seathold.Hold{
+		ID:         seathold.NewID(),
+		UserID:     userId,
+		ShowtimeID: showtimeId,
+		SeatIDs:    req.SeatIDs,
+		ExpiresAt:  time.Now().Add(s.holdTTL),
+	}
+	replaced, err := s.holds.Create(ctx, hold)
+	if err != nil {
+		return nil, err
+	}
+	for _, old := range replaced {
+		if freed := seatsNotIn(old.SeatIDs, hold.SeatIDs); len(freed) > 0 {
+			s.seats.Publish(showtimeId, freed, seatstream.StateReleased)
+		}
+	}
+	s.seats.Publish(showtimeId, hold.SeatIDs, seatstream.StateHeld)
+	return hold, nil
+}
+
+// seatsNotIn returns the seats of from that are not in keep.
+func seatsNotIn(from, keep []uint32) []uint32 {
+	kept := make(map[uint32]bool, len(keep))
+	for _, seat := range keep {
+		kept[seat] = true
+	}
+	seats := []uint32{}
+	for _, seat := range from {
+		if !kept[seat] {
+			seats = append(seats, seat)
+		}
+	}
+	return seats
+}
+
+func (s *service) ReleaseHold(ctx context.Context, userId int, holdId string) error {
+	hold, err := s.holds.Get(ctx, holdId)
+	if err != nil {
+		return err
+	}
+	if hold.UserID != userId {
+		return seathold.ErrHoldNotFound
+	}
+	return s.releaseHold(ctx, hold)
+}
+
+// ExpireHolds releases holds past their expiry. Bookings made from them are
+// deleted unless they were paid in the meantime, for example through a
+// webhook this gateway instance did not see. Holds with a payment order
+// were extended by ProcessPayment to cover checkout. A hold is only removed
+// once its release succeeds, so failures are retried on the next run.
+func (s *service) ExpireHolds(ctx context.Context) {
+	expired, err := s.holds.Expired(ctx, time.Now())
+	if err != nil {
+		log.Printf("failed to list expired seat holds: %v", err)
+		return
+	}
+	for _, hold := range expired {
+		if err := s.releaseHold(ctx, hold); err != nil {
+			log.Printf("failed to release expired seat hold %s, will retry: %v", hold.ID, err)
+		}
+	}
+}
+
+// releaseHold frees the seats, deleting the booking made from the hold if
+// there is one and it is still unpaid.
+func (s *service) releaseHold(ctx context.Context, hold *seathold.Hold) error {
+	if hold.BookingID != 0 {
+		booking, err := s.GetBookingByID(ctx, hold.BookingID)
+		if err != nil {
+			return err
+		}
+		if !isUnpaid(booking.PaymentStatus) {
+			return s.holds.Delete(ctx, hold.ID)
+		}
+		_, err = s.bookingClient.DeleteBookingByBookingID(ctx, &movie_booking.DeleteBookingByIDRequest{
+			BookingId: int32(hold.BookingID),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := s.holds.Delete(ctx, hold.ID); err != nil {
+		return err
+	}
+	s.seats.Publish(hold.ShowtimeID, hold.SeatIDs, seatstream.StateReleased)
+	return nil
+}
+
+func isUnpaid(paymentStatus string) bool {
+	switch strings.ToLower(paymentStatus) {
+	case "", "pending", "failed":
+		return true
+	}
+	return false
+}
+
+func sameSeats(a, b []uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[uint32]bool, len(a))
+	for _, seat := range a {
+		set[seat] = true
+	}
+	for _, seat := range b {
+		if !set[seat] {
+			return false
+		}
+	}
+	return true
+}
+
 // Booking service handler
-func (s *service) ListBookingsByUser(ctx context.Context, userId int) ([]Booking, error) {
+// ListBookingsByUser returns one page of the user's bookings. Booking-svc
+// returns them all at once, so filtering, sorting and paging happen here.
+// Showtimes are only looked up when the filter or sort needs them.
+func (s *service) ListBookingsByUser(ctx context.Context, userId int, filter BookingFilter, page pagination.Request) (*pagination.Page[Booking], error) {
+	cursor, err := parseBookingCursor(page.Cursor, filter.Sort)
+	if err != nil {
+		return nil, err
+	}
 	response, err := s.bookingClient.ListBookingsByUser(ctx, &movie_booking.ListBookingsByUserRequest{
 		UserId: uint32(userId),
 	})
 	if err != nil {
 		return nil, err
 	}
-	bookings := []Booking{}
+
+	needShowtimes := filter.When != "" || filter.TheaterID != 0 || filter.Sort == "show_time"
+	showtimes := make(map[uint32]*movie_booking.Showtime)
+	now := time.Now()
+	rows := []bookingRow{}
 	for _, res := range response.Bookings {
-		seats := make([]BookingSeat, len(res.BookingSeats))
-		for i, seat := range res.BookingSeats {
-			seats[i] = BookingSeat{
-				BookingID: uint(seat.BookingId),
-				SeatID:    uint(seat.SeatId),
+		if filter.PaymentStatus != "" && !strings.EqualFold(res.PaymentStatus, filter.PaymentStatus) {
+			continue
+		}
+		bookingDate := res.BookingDate.AsTime()
+		if (!filter.from.IsZero() && bookingDate.Before(filter.from)) || (!filter.to.IsZero() && !bookingDate.Before(filter.to)) {
+			continue
+		}
+		row := bookingRow{booking: toBooking(res)}
+		if needShowtimes {
+			showtime, ok := showtimes[res.ShowtimeId]
+			if !ok {
+				st, err := s.theaterClient.GetShowtimeByID(ctx, &movie_booking.GetShowtimeByIDRequest{
+					ShowtimeId: int32(res.ShowtimeId),
+				})
+				if err != nil {
+					return nil, err
+				}
+				showtime = st.Showtime
+				showtimes[res.ShowtimeId] = showtime
 			}
+			row.start = showStart(showtime.ShowDate.AsTime(), showtime.ShowTime.AsTime())
+			if showtime.TheaterScreen != nil {
+				row.theaterID = int(showtime.TheaterScreen.TheaterID)
+			}
+		}
+		if filter.TheaterID != 0 && row.theaterID != filter.TheaterID {
+			continue
 		}
-		booking := Booking{
-			BookingID:     uint(res.BookingId),
-			UserID:        uint(res.UserId),
-			ShowtimeID:    uint(res.ShowtimeId),
-			BookingDate:   res.BookingDate.AsTime(),
-			TotalAmount:   res.TotalAmount,
-			PaymentStatus: res.PaymentStatus,
-			BookingSeats:  seats,
+		if (filter.When == "upcoming" && !row.start.After(now)) || (filter.When == "past" && row.start.After(now)) {
+			continue
 		}
-		bookings = append(bookings, booking)
+		row.key = row.sortKey(filter.Sort)
+		rows = append(rows, row)
+	}
+
+	desc := filter.Order == "desc"
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].before(rows[j].key, rows[j].booking.BookingID, desc)
+	})
+	result := pagination.Paginate(rows, page,
+		func(row bookingRow) string {
+			return formatBookingCursor(filter.Sort, row.key, row.booking.BookingID)
+		},
+		func(row bookingRow) bool {
+			return !row.before(cursor.key, cursor.id, desc) && !(row.key == cursor.key && row.booking.BookingID == cursor.id)
+		},
+	)
+	bookings := make([]Booking, len(result.Items))
+	for i, row := range result.Items {
+		bookings[i] = row.booking
+	}
+	return &pagination.Page[Booking]{
+		Items:      bookings,
+		NextCursor: result.NextCursor,
+		Total:      result.Total,
+	}, nil
+}
+
+type bookingRow struct {
+	booking   Booking
+	start     time.Time
+	theaterID int
+	key       float64
+}
+
+func (r bookingRow) sortKey(field string) float64 {
+	switch field {
+	case "show_time":
+		return float64(r.start.UnixMilli())
+	case "total_amount":
+		return r.booking.TotalAmount
+	}
+	return float64(r.booking.BookingDate.UnixMilli())
+}
+
+// before reports whether the row sorts ahead of a row with key and id. Ties
+// on the key are broken by booking id in the same direction.
+func (r bookingRow) before(key float64, id uint, desc bool) bool {
+	if r.key != key {
+		return (r.key < key) != desc
 	}
-	return bookings, nil
+	return (r.booking.BookingID < id) != desc
+}
+
+type bookingCursor struct {
+	key float64
+	id  uint
+}
+
+func formatBookingCursor(sortField string, key float64, id uint) string {
+	return fmt.Sprintf("%s:%s:%d", sortField, strconv.FormatFloat(key, 'g', -1, 64), id)
+}
+
+// parseBookingCursor reads "<sort>:<key>:<booking id>". A cursor made for a
+// different sort is rejected.
+func parseBookingCursor(cursor, sortField string) (bookingCursor, error) {
+	if cursor == "" {
+		return bookingCursor{}, nil
+	}
+	parts := strings.Split(cursor, ":")
+	if len(parts) != 3 || parts[0] != sortField {
+		return bookingCursor{}, pagination.ErrInvalidCursor
+	}
+	key, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return bookingCursor{}, pagination.ErrInvalidCursor
+	}
+	id, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		return bookingCursor{}, pagination.ErrInvalidCursor
+	}
+	return bookingCursor{key: key, id: uint(id)}, nil
+}
+
+func toBooking(res *movie_booking.Booking) Booking {
+	seats := make([]BookingSeat, len(res.BookingSeats))
+	for i, seat := range res.BookingSeats {
+		seats[i] = BookingSeat{
+			BookingID: uint(seat.BookingId),
+			SeatID:    uint(seat.SeatId),
+		}
+	}
+	return Booking{
+		BookingID:     uint(res.BookingId),
+		UserID:        uint(res.UserId),
+		ShowtimeID:    uint(res.ShowtimeId),
+		BookingDate:   res.BookingDate.AsTime(),
+		TotalAmount:   res.TotalAmount,
+		PaymentStatus: res.PaymentStatus,
+		BookingSeats:  seats,
+	}
+}
+
+func (s *service) BookingOwner(ctx context.Context, id int) (int, error) {
+	response, err := s.bookingClient.GetBookingByID(ctx, &movie_booking.GetBookingByIDRequest{
+		BookingId: uint32(id),
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(response.Booking.UserId), nil
+}
+
+// CancelBooking cancels the booking if the theater's rules still allow it and
+// frees its seats. Paid bookings are refunded less the cancellation fee.
+func (s *service) CancelBooking(ctx context.Context, id int) (*Cancellation, error) {
+	booking, err := s.GetBookingByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(booking.PaymentStatus, bookingStatusCancelled) {
+		return nil, ErrBookingCancelled
+	}
+	response, err := s.theaterClient.GetShowtimeByID(ctx, &movie_booking.GetShowtimeByIDRequest{
+		ShowtimeId: int32(booking.ShowtimeID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	theaterId := 0
+	if response.Showtime.TheaterScreen != nil {
+		theaterId = int(response.Showtime.TheaterScreen.TheaterID)
+	}
+	start := showStart(response.Showtime.ShowDate.AsTime(), response.Showtime.ShowTime.AsTime())
+	fee, err := s.cancellations.For(theaterId).Quote(booking.TotalAmount, time.Until(start))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.bookingClient.UpdateBookingStatusByBookingID(ctx, &movie_booking.UpdateBookingStatusByBookingIDRequest{
+		BookingId: int32(id),
+		Status:    bookingStatusCancelled,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cancelled := &Cancellation{
+		BookingID:    booking.BookingID,
+		Status:       bookingStatusCancelled,
+		TotalAmount:  booking.TotalAmount,
+		RefundStatus: RefundNotRequired,
+		CancelledAt:  time.Now().UTC(),
+	}
+	if !isUnpaid(booking.PaymentStatus) {
+		cancelled.Fee = fee
+		cancelled.RefundAmount = booking.TotalAmount - fee
+	}
+	if cancelled.RefundAmount > 0 {
+		if err := s.requestRefund(ctx, booking, cancelled); err != nil {
+			return nil, err
+		}
+		cancelled.RefundStatus = RefundPending
+	}
+
+	if hold, err := s.holds.FindByBooking(ctx, id); err == nil {
+		s.holds.Delete(ctx, hold.ID)
+	}
+	s.publishFreedSeats(ctx, int(response.Showtime.ScreenId), booking)
+	return cancelled, nil
+}
+
+// requestRefund records the refund owed for a cancelled booking. The payment
+// service has no refund RPC yet, so the request goes to the event outbox for
+// settlement. A booking whose refund cannot be recorded is restored rather
+// than left cancelled with nothing owed on record.
+func (s *service) requestRefund(ctx context.Context, booking *Booking, cancelled *Cancellation) error {
+	err := s.events.Persist(rabbitmq.RefundRequested{
+		BookingID:   int(booking.BookingID),
+		UserID:      int(booking.UserID),
+		Amount:      cancelled.RefundAmount,
+		TotalAmount: booking.TotalAmount,
+		Fee:         cancelled.Fee,
+	})
+	if err == nil {
+		return nil
+	}
+	log.Printf("failed to record the refund for booking %d: %v", booking.BookingID, err)
+	_, restoreErr := s.bookingClient.UpdateBookingStatusByBookingID(ctx, &movie_booking.UpdateBookingStatusByBookingIDRequest{
+		BookingId: int32(booking.BookingID),
+		Status:    booking.PaymentStatus,
+	})
+	if restoreErr != nil {
+		log.Printf("booking %d is cancelled without a refund of %.2f on record, restoring it failed: %v",
+			booking.BookingID, cancelled.RefundAmount, restoreErr)
+	}
+	return ErrRefundUnavailable
+}
+
+// publishFreedSeats announces the booking's seats as released once
+// booking-svc lists them as available again. Seats it still counts as
+// booked, or that cannot be checked, are not announced.
+func (s *service) publishFreedSeats(ctx context.Context, screenId int, booking *Booking) {
+	available, err := s.theaterClient.GetAvailableSeatsByScreenIDAndShowTimeID(ctx, &movie_booking.GetAvailableSeatsByScreenIDAndShowTimeIDRequest{
+		ScreenId:   int32(screenId),
+		ShowtimeId: int32(booking.ShowtimeID),
+	})
+	if err != nil {
+		log.Printf("failed to confirm the seats of cancelled booking %d are free: %v", booking.BookingID, err)
+		return
+	}
+	free := make(map[uint32]bool, len(available.Seats))
+	for _, seat := range available.Seats {
+		free[uint32(seat.Id)] = true
+	}
+	seatIds := make([]uint32, 0, len(booking.BookingSeats))
+	for _, seat := range booking.BookingSeats {
+		if free[uint32(seat.SeatID)] {
+			seatIds = append(seatIds, uint32(seat.SeatID))
+		}
+	}
+	if len(seatIds) < len(booking.BookingSeats) {
+		log.Printf("booking-svc still counts %d seats of cancelled booking %d as booked",
+			len(booking.BookingSeats)-len(seatIds), booking.BookingID)
+	}
+	if len(seatIds) > 0 {
+		s.seats.Publish(int(booking.ShowtimeID), seatIds, seatstream.StateReleased)
+	}
+}
+
+// Ticket collects what is printed on the e-ticket of a paid booking and
+// returns it with the signed QR payload.
+func (s *service) Ticket(ctx context.Context, bookingId int) (*ticket.Ticket, string, error) {
+	booking, err := s.GetBookingByID(ctx, bookingId)
+	if err != nil {
+		return nil, "", err
+	}
+	if strings.EqualFold(booking.PaymentStatus, bookingStatusCancelled) {
+		return nil, "", ErrBookingCancelled
+	}
+	if isUnpaid(booking.PaymentStatus) {
+		return nil, "", ErrTicketUnpaid
+	}
+	// Without a key nothing can be issued; skip the lookups below.
+	if s.tickets == nil {
+		return nil, "", ticket.ErrNoSigningKey
+	}
+	response, err := s.theaterClient.GetShowtimeByID(ctx, &movie_booking.GetShowtimeByIDRequest{
+		ShowtimeId: int32(booking.ShowtimeID),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	showtime := response.Showtime
+	if showtime == nil {
+		return nil, "", ErrTicketDetails
+	}
+	t := &ticket.Ticket{
+		Reference:   ticket.Reference(bookingId),
+		BookingID:   bookingId,
+		ShowtimeID:  int(booking.ShowtimeID),
+		ShowTime:    showStart(showtime.ShowDate.AsTime(), showtime.ShowTime.AsTime()),
+		TotalAmount: booking.TotalAmount,
+		IssuedAt:    time.Now().UTC(),
+	}
+
+	movie := showtime.Movie
+	if movie == nil {
+		res, err := s.movieBooking.GetMovieDetailsByID(ctx, &movie_booking.GetMovieDetailsRequest{
+			MovieId: uint32(showtime.MovieId),
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		movie = res.Movie
+	}
+	if movie == nil {
+		return nil, "", ErrTicketDetails
+	}
+	t.Movie = movie.Title
+
+	if screen := showtime.TheaterScreen; screen != nil {
+		t.Screen = int(screen.ScreenNumber)
+		theater := screen.Theater
+		if theater == nil {
+			res, err := s.theaterClient.GetTheaterByID(ctx, &movie_booking.GetTheaterByIDRequest{
+				TheaterId: screen.TheaterID,
+			})
+			if err != nil {
+				return nil, "", err
+			}
+			theater = res.Theater
+		}
+		if theater == nil {
+			return nil, "", ErrTicketDetails
+		}
+		t.Theater = theater.Name
+		t.City = theater.City
+	}
+
+	for _, seat := range booking.BookingSeats {
+		res, err := s.GetSeatBySeatID(ctx, int(seat.SeatID))
+		if err != nil {
+			return nil, "", err
+		}
+		t.Seats = append(t.Seats, res.SeatNumber)
+	}
+
+	token, err := s.tickets.Sign(t)
+	if err != nil {
+		return nil, "", err
+	}
+	return t, token, nil
+}
+
+// showStart combines the date of a showtime with its time of day.
+func showStart(date, clock time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, clock.Location())
 }
 
 func (s *service) GetBookingByID(ctx context.Context, id int) (*Booking, error) {
@@ -234,17 +843,49 @@ func (s *service) GetBookingByID(ctx context.Context, id int) (*Booking, error)
 	}, nil
 }
 
+// CreateBooking books the seats of a hold the user owns. The hold is kept,
+// with a fresh expiry, until the payment settles it.
 func (s *service) CreateBooking(ctx context.Context, bookingReq CreateBookingRequest) (*Booking, error) {
+	if bookingReq.HoldID == "" {
+		return nil, ErrHoldRequired
+	}
+	hold, err := s.holds.Get(ctx, bookingReq.HoldID)
+	if err != nil {
+		return nil, err
+	}
+	if hold.UserID != bookingReq.UserID {
+		return nil, seathold.ErrHoldNotFound
+	}
+	if hold.BookingID != 0 {
+		return nil, ErrHoldUsed
+	}
+	if hold.ShowtimeID != bookingReq.ShowtimeID {
+		return nil, ErrHoldMismatch
+	}
+	if len(bookingReq.SeatIDs) == 0 {
+		bookingReq.SeatIDs = hold.SeatIDs
+	} else if !sameSeats(bookingReq.SeatIDs, hold.SeatIDs) {
+		return nil, ErrHoldMismatch
+	}
+	total, err := s.priceSeats(ctx, bookingReq)
+	if err != nil {
+		return nil, err
+	}
 	response, err := s.bookingClient.CreateBooking(ctx, &movie_booking.CreateBookingRequest{
 		UserId:        uint32(bookingReq.UserID),
 		ShowtimeId:    uint32(bookingReq.ShowtimeID),
-		TotalAmount:   bookingReq.TotalAmount,
+		TotalAmount:   total,
 		PaymentStatus: "",
 		SeatIds:       bookingReq.SeatIDs,
 	})
 	if err != nil {
 		return nil, err
 	}
+	hold.BookingID = int(response.Booking.BookingId)
+	hold.ExpiresAt = time.Now().Add(s.holdTTL)
+	if err := s.holds.Update(ctx, hold); err != nil {
+		log.Printf("failed to attach booking %d to seat hold %s: %v", hold.BookingID, hold.ID, err)
+	}
 	bookingSeats := []BookingSeat{}
 	for _, res := range response.Booking.BookingSeats {
 		seat := BookingSeat{
@@ -253,6 +894,13 @@ func (s *service) CreateBooking(ctx context.Context, bookingReq CreateBookingReq
 		}
 		bookingSeats = append(bookingSeats, seat)
 	}
+	s.events.Publish(rabbitmq.BookingCreated{
+		BookingID:   int(response.Booking.BookingId),
+		UserID:      bookingReq.UserID,
+		ShowtimeID:  bookingReq.ShowtimeID,
+		SeatIDs:     bookingReq.SeatIDs,
+		TotalAmount: total,
+	})
 	return &Booking{
 		BookingID:     uint(response.Booking.BookingId),
 		UserID:        uint(response.Booking.UserId),
@@ -264,6 +912,38 @@ func (s *service) CreateBooking(ctx context.Context, bookingReq CreateBookingReq
 	}, nil
 }
 
+// priceSeats sums SeatCategoryPrice over the requested seats in paise, so a
+// client total that differs by float rounding alone is still accepted.
+func (s *service) priceSeats(ctx context.Context, bookingReq CreateBookingRequest) (float64, error) {
+	if len(bookingReq.SeatIDs) == 0 {
+		return 0, ErrNoSeats
+	}
+	var total int64
+	seen := make(map[uint32]bool, len(bookingReq.SeatIDs))
+	for _, seatId := range bookingReq.SeatIDs {
+		if seen[seatId] {
+			return 0, fmt.Errorf("%w: %d", ErrDuplicateSeat, seatId)
+		}
+		seen[seatId] = true
+		seat, err := s.GetSeatBySeatID(ctx, int(seatId))
+		if err != nil {
+			return 0, err
+		}
+		if bookingReq.ScreenID != 0 && seat.ScreenID != int(bookingReq.ScreenID) {
+			return 0, fmt.Errorf("%w: %d", ErrSeatNotOnScreen, seatId)
+		}
+		total += toPaise(seat.SeatCategoryPrice)
+	}
+	if bookingReq.TotalAmount != 0 && toPaise(bookingReq.TotalAmount) != total {
+		return 0, fmt.Errorf("%w: expected %.2f", ErrAmountMismatch, float64(total)/100)
+	}
+	return float64(total) / 100, nil
+}
+
+func toPaise(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
 func (s *service) Register(ctx context.Context, signUpData *User) error {
 	reqData := user_admin.RegisterUserRequest{
 		Username:  signUpData.Username,
@@ -277,6 +957,10 @@ func (s *service) Register(ctx context.Context, signUpData *User) error {
 	if _, err := s.userAdmin.RegisterUser(ctx, &reqData); err != nil {
 		return err
 	}
+	s.events.Publish(rabbitmq.UserRegistered{
+		Username: signUpData.Username,
+		Email:    signUpData.Email,
+	})
 	return nil
 }
 
@@ -302,21 +986,6 @@ func (s *service) Login(ctx context.Context, loginData *User) (string, error) {
 	return res.Token, nil
 }
 
-func (s *service) GetUserIDFromToken(ctx context.Context, authorization string) (int, error) {
-	tokenParts := strings.Split(authorization, "Bearer ")
-	token := tokenParts[1]
-	var userId int
-
-	response, err := s.auth.GetUserID(ctx, &auth.GetUserIDRequest{
-		Token: token,
-	})
-	userId = int(response.UserId)
-	if err != nil {
-		return 0, err
-	}
-	return userId, nil
-}
-
 func (s *service) GetProfile(ctx context.Context, userId int) (*UserProfileDetails, error) {
 	response, err := s.userAdmin.GetUserProfile(ctx, &user_admin.GetProfileRequest{
 		UserId: int32(userId),
@@ -917,6 +1586,8 @@ func (s *service) ListSeatsbyScreenID(ctx context.Context, screenId int) ([]Seat
 	return seats, nil
 }
 
+// ListAvailableSeatsbyScreenIDAndShowTimeID leaves out seats under an
+// active hold, including the caller's own.
 func (s *service) ListAvailableSeatsbyScreenIDAndShowTimeID(ctx context.Context, screenId, showtimeId int) ([]SeatsByScreenIDRes, error) {
 	response, err := s.theaterClient.GetAvailableSeatsByScreenIDAndShowTimeID(ctx, &movie_booking.GetAvailableSeatsByScreenIDAndShowTimeIDRequest{
 		ScreenId:   int32(screenId),
@@ -925,6 +1596,10 @@ func (s *service) ListAvailableSeatsbyScreenIDAndShowTimeID(ctx context.Context,
 	if err != nil {
 		return nil, err
 	}
+	held, err := s.holds.HeldSeats(ctx, showtimeId, 0)
+	if err != nil {
+		return nil, err
+	}
 	seats := []SeatsByScreenIDRes{}
 
 	for _, res := range response.Seats {
@@ -948,6 +1623,9 @@ func (s *service) ListAvailableSeatsbyScreenIDAndShowTimeID(ctx context.Context,
 				SeatCategoryName: res.SeatCategory.SeatCategoryName,
 			},
 		}
+		if held[uint32(res.Id)] {
+			continue
+		}
 		seats = append(seats, *seat)
 	}
 	return seats, nil
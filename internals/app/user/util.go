@@ -1,17 +1,12 @@
 package user
 
 import (
-	"fmt"
-	"log"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
-	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
-	"github.com/streadway/amqp"
-
-	"math/rand"
 
 	"github.com/aparnasukesh/inter-communication/user_admin"
 )
@@ -27,113 +22,48 @@ func BuildGetUserProfile(res *user_admin.GetProfileResponse) (*UserProfileDetail
 	}, nil
 }
 
-func ExtractErrorMessage(err error) string {
-	if err == nil {
-		return ""
-	}
-	errMsg := err.Error()
-	if index := strings.Index(errMsg, "desc = "); index != -1 {
-		return errMsg[index+len("desc = "):]
-	}
-	return errMsg
-}
+const socketWriteWait = 10 * time.Second
 
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true
-	},
-}
+// maxPendingReplies bounds the help-desk calls one socket may have waiting,
+// so a flood of frames cannot pile up requests on the RPC queue.
+const maxPendingReplies = 3
 
-func NewUpgrader(ctx *gin.Context) (conn *websocket.Conn, err error) {
-	conn, err = upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
-	if err != nil {
-		log.Println("Failed to upgrade connection:", err)
-		return
-	}
-	return
-}
+var ErrTooManyChatSockets = errors.New("too many open chat connections")
 
-type RabbitMQQueueChannel struct {
-	ch *amqp.Channel
-	*amqp.Queue
+// ChatSocketConfig bounds help-desk chat sockets. Without AllowedOrigins
+// only same-origin browsers may connect; "*" allows any origin. A zero
+// MaxConnsPerUser means no cap.
+type ChatSocketConfig struct {
+	AllowedOrigins  []string
+	PingInterval    time.Duration
+	IdleTimeout     time.Duration
+	MaxMessageSize  int64
+	MaxConnsPerUser int
 }
 
-func RabbitMQQueue(conn *amqp.Connection, queueName string) (*RabbitMQQueueChannel, error) {
-	ch, err := conn.Channel()
-	if err != nil {
-		return nil, err
-	}
-	q, err := ch.QueueDeclare(
-		queueName,
-		false,
-		false,
-		false,
-		false,
-		nil,
-	)
-	if err != nil {
-		return nil, err
+func newUpgrader(allowedOrigins []string) websocket.Upgrader {
+	upgrader := websocket.Upgrader{}
+	if len(allowedOrigins) == 0 {
+		return upgrader
 	}
-	return &RabbitMQQueueChannel{
-		ch:    ch,
-		Queue: &q,
-	}, nil
-}
-
-func randomString(length int) string {
-	rand.Seed(time.Now().UnixNano())
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	result := make([]byte, length)
-	for i := range result {
-		result[i] = charset[rand.Intn(len(charset))]
+	upgrader.CheckOrigin = func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		for _, allowed := range allowedOrigins {
+			if allowed == "*" || strings.EqualFold(strings.TrimSpace(allowed), origin) {
+				return true
+			}
+		}
+		return false
 	}
-	return string(result)
-}
-
-func setupReplyQueue(queue *RabbitMQQueueChannel) (amqp.Queue, error) {
-	return queue.ch.QueueDeclare(
-		"",
-		false,
-		true,
-		true,
-		false,
-		nil,
-	)
-}
-
-func sendMessage(correlationID string, queue *RabbitMQQueueChannel, body []byte, replyQueue amqp.Queue) error {
-	return queue.ch.Publish(
-		"",
-		queue.Name,
-		false,
-		false,
-		amqp.Publishing{
-			ContentType:   "text/plain",
-			Body:          body,
-			ReplyTo:       replyQueue.Name,
-			CorrelationId: correlationID,
-		},
-	)
+	return upgrader
 }
 
-func waitForResponse(queue *RabbitMQQueueChannel, replyQueue amqp.Queue, correlationID string) (*amqp.Delivery, error) {
-	messages, err := queue.ch.Consume(
-		replyQueue.Name,
-		"",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	for msg := range messages {
-		if msg.CorrelationId == correlationID {
-			return &msg, nil
-		}
-	}
-	return nil, fmt.Errorf("response with correlation ID %s not found", correlationID)
+// closeSocket sends a close frame with code and reason, then closes conn.
+func closeSocket(conn *websocket.Conn, code int, reason string) {
+	msg := websocket.FormatCloseMessage(code, reason)
+	conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+	conn.Close()
 }
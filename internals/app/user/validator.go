@@ -1,8 +1,10 @@
 package user
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/go-playground/validator"
 )
@@ -39,3 +41,71 @@ func ValidateUser(user User) error {
 	}
 	return nil
 }
+
+func ValidatePaymentFailure(req PaymentFailureRequest) error {
+	validate := validator.New()
+
+	err := validate.Struct(req)
+	if err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		errorMessages := make([]string, len(validationErrors))
+
+		for i, validationErr := range validationErrors {
+			switch validationErr.Tag() {
+			case "required":
+				errorMessages[i] = fmt.Sprintf("%s is required", validationErr.Field())
+			case "max":
+				errorMessages[i] = fmt.Sprintf("%s is too long, Maximum %s letters allowed", validationErr.Field(), validationErr.Param())
+			default:
+				errorMessages[i] = "Validation failed"
+			}
+		}
+
+		return errors.New(strings.Join(errorMessages, ", "))
+	}
+	return nil
+}
+
+// ValidateBookingFilter checks the filter and fills in its defaults.
+func ValidateBookingFilter(filter *BookingFilter) error {
+	switch filter.When {
+	case "", "upcoming", "past":
+	default:
+		return errors.New("when must be upcoming or past")
+	}
+	switch filter.Sort {
+	case "":
+		filter.Sort = "booking_date"
+	case "booking_date", "show_time", "total_amount":
+	default:
+		return errors.New("sort must be booking_date, show_time or total_amount")
+	}
+	switch filter.Order {
+	case "":
+		filter.Order = "desc"
+	case "asc", "desc":
+	default:
+		return errors.New("order must be asc or desc")
+	}
+	if filter.From != "" {
+		from, err := time.Parse("2006-01-02", filter.From)
+		if err != nil {
+			return errors.New("from must be a date like 2006-01-02")
+		}
+		filter.from = from
+	}
+	if filter.To != "" {
+		to, err := time.Parse("2006-01-02", filter.To)
+		if err != nil {
+			return errors.New("to must be a date like 2006-01-02")
+		}
+		filter.to = to.AddDate(0, 0, 1)
+	}
+	if !filter.from.IsZero() && !filter.to.IsZero() && !filter.from.Before(filter.to) {
+		return errors.New("from must not be after to")
+	}
+	if filter.TheaterID < 0 {
+		return errors.New("theater_id must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package user
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aparnasukesh/api-gateway/pkg/pagination"
+)
+
+func TestValidateBookingFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   BookingFilter
+		wantErr  bool
+		wantSort string
+		wantFrom string
+		wantTo   string
+	}{
+		{name: "defaults", filter: BookingFilter{}, wantSort: "booking_date"},
+		{name: "upcoming by show time", filter: BookingFilter{When: "upcoming", Sort: "show_time", Order: "asc"}, wantSort: "show_time"},
+		{name: "past by amount", filter: BookingFilter{When: "past", Sort: "total_amount"}, wantSort: "total_amount"},
+		{name: "unknown when", filter: BookingFilter{When: "tomorrow"}, wantErr: true},
+		{name: "unknown sort", filter: BookingFilter{Sort: "seat"}, wantErr: true},
+		{name: "unknown order", filter: BookingFilter{Order: "up"}, wantErr: true},
+		{name: "date range", filter: BookingFilter{From: "2024-05-01", To: "2024-05-31"}, wantSort: "booking_date", wantFrom: "2024-05-01", wantTo: "2024-06-01"},
+		{name: "single day", filter: BookingFilter{From: "2024-05-01", To: "2024-05-01"}, wantSort: "booking_date", wantFrom: "2024-05-01", wantTo: "2024-05-02"},
+		{name: "from after to", filter: BookingFilter{From: "2024-05-02", To: "2024-05-01"}, wantErr: true},
+		{name: "bad from", filter: BookingFilter{From: "01/05/2024"}, wantErr: true},
+		{name: "bad to", filter: BookingFilter{To: "2024-13-01"}, wantErr: true},
+		{name: "negative theater", filter: BookingFilter{TheaterID: -1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter
+			err := ValidateBookingFilter(&filter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if filter.Sort != tt.wantSort {
+				t.Errorf("sort = %q, want %q", filter.Sort, tt.wantSort)
+			}
+			if filter.Order == "" {
+				t.Errorf("order was not defaulted")
+			}
+			if got := formatDate(filter.from); got != tt.wantFrom {
+				t.Errorf("from = %q, want %q", got, tt.wantFrom)
+			}
+			if got := formatDate(filter.to); got != tt.wantTo {
+				t.Errorf("to = %q, want %q", got, tt.wantTo)
+			}
+		})
+	}
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func TestBookingCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		sort   string
+		key    float64
+		id     uint
+		parsed string
+		want   error
+	}{
+		{name: "booking date", sort: "booking_date", key: 1714521600000, id: 42},
+		{name: "fractional amount", sort: "total_amount", key: 349.5, id: 7},
+		{name: "other sort", sort: "show_time", key: 1, id: 1, parsed: "booking_date", want: pagination.ErrInvalidCursor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor := formatBookingCursor(tt.sort, tt.key, tt.id)
+			parsed := tt.parsed
+			if parsed == "" {
+				parsed = tt.sort
+			}
+			got, err := parseBookingCursor(cursor, parsed)
+			if err != tt.want {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if tt.want == nil && (got.key != tt.key || got.id != tt.id) {
+				t.Fatalf("got %+v, want key %v id %d", got, tt.key, tt.id)
+			}
+		})
+	}
+
+	for _, cursor := range []string{"booking_date", "booking_date:1", "booking_date:x:1", "booking_date:1:-1", "booking_date:1:2:3"} {
+		if _, err := parseBookingCursor(cursor, "booking_date"); err != pagination.ErrInvalidCursor {
+			t.Errorf("parseBookingCursor(%q) err = %v, want %v", cursor, err, pagination.ErrInvalidCursor)
+		}
+	}
+}
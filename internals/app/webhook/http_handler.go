@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/aparnasukesh/api-gateway/pkg/audit"
+	"github.com/aparnasukesh/api-gateway/pkg/razorpay"
+	"github.com/gin-gonic/gin"
+)
+
+const maxWebhookBody = 1 << 20
+
+type Handler struct {
+	svc           Service
+	webhookSecret string
+}
+
+func NewHttpHandler(svc Service, webhookSecret string) *Handler {
+	return &Handler{
+		svc:           svc,
+		webhookSecret: webhookSecret,
+	}
+}
+
+// MountRoutes registers the webhook receivers. They are called by payment
+// providers, so they authenticate by signature instead of a bearer token.
+func (h *Handler) MountRoutes(r *gin.RouterGroup) {
+	r.POST("/razorpay", h.razorpay)
+}
+
+func (h *Handler) razorpay(ctx *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxWebhookBody))
+	if err != nil {
+		h.responseWithError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	signature := ctx.GetHeader("X-Razorpay-Signature")
+	if err := razorpay.VerifyWebhookSignature(h.webhookSecret, body, signature); err != nil {
+		if errors.Is(err, razorpay.ErrNoSecret) {
+			h.responseWithError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		audit.Record(ctx, "webhook.signature_rejected", map[string]string{
+			"provider": "razorpay",
+			"event_id": ctx.GetHeader("X-Razorpay-Event-Id"),
+			"reason":   err.Error(),
+		})
+		h.responseWithError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	event := RazorpayEvent{}
+	if err := json.Unmarshal(body, &event); err != nil {
+		h.responseWithError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	event.ID = ctx.GetHeader("X-Razorpay-Event-Id")
+	if event.ID == "" {
+		sum := sha256.Sum256(body)
+		event.ID = hex.EncodeToString(sum[:])
+	}
+
+	err = h.svc.HandleRazorpayEvent(ctx, event)
+	switch {
+	case errors.Is(err, ErrDuplicateEvent):
+		h.response(ctx, http.StatusOK, "duplicate event ignored")
+	case errors.Is(err, ErrEventInFlight):
+		// Not 2xx, so Razorpay retries in case the first delivery fails.
+		h.responseWithError(ctx, http.StatusConflict, err)
+	case errors.Is(err, ErrMalformedEvent):
+		h.responseWithError(ctx, http.StatusBadRequest, err)
+	case err != nil:
+		h.responseWithError(ctx, http.StatusBadGateway, err)
+	default:
+		h.response(ctx, http.StatusOK, "event processed")
+	}
+}
@@ -0,0 +1,60 @@
+package webhook
+
+import "strconv"
+
+// RazorpayEvent is the envelope Razorpay posts for every webhook event.
+type RazorpayEvent struct {
+	ID        string          `json:"-"`
+	Event     string          `json:"event"`
+	AccountID string          `json:"account_id"`
+	Contains  []string        `json:"contains"`
+	Payload   RazorpayPayload `json:"payload"`
+	CreatedAt int64           `json:"created_at"`
+}
+
+type RazorpayPayload struct {
+	Payment *struct {
+		Entity RazorpayPayment `json:"entity"`
+	} `json:"payment"`
+	Order *struct {
+		Entity RazorpayOrder `json:"entity"`
+	} `json:"order"`
+	Refund *struct {
+		Entity RazorpayRefund `json:"entity"`
+	} `json:"refund"`
+}
+
+type RazorpayPayment struct {
+	ID               string            `json:"id"`
+	OrderID          string            `json:"order_id"`
+	Status           string            `json:"status"`
+	Amount           int64             `json:"amount"`
+	Currency         string            `json:"currency"`
+	Method           string            `json:"method"`
+	ErrorCode        string            `json:"error_code"`
+	ErrorDescription string            `json:"error_description"`
+	ErrorReason      string            `json:"error_reason"`
+	Notes            map[string]string `json:"notes"`
+}
+
+type RazorpayOrder struct {
+	ID      string            `json:"id"`
+	Status  string            `json:"status"`
+	Amount  int64             `json:"amount"`
+	Receipt string            `json:"receipt"`
+	Notes   map[string]string `json:"notes"`
+}
+
+type RazorpayRefund struct {
+	ID        string `json:"id"`
+	PaymentID string `json:"payment_id"`
+	Amount    int64  `json:"amount"`
+	Status    string `json:"status"`
+}
+
+// bookingID reads the booking_id note set when the order was created. It is
+// zero when the order carries no such note.
+func (p RazorpayPayment) bookingID() int32 {
+	id, _ := strconv.Atoi(p.Notes["booking_id"])
+	return int32(id)
+}
@@ -0,0 +1,18 @@
+package webhook
+
+import (
+	"github.com/aparnasukesh/api-gateway/pkg/common"
+	"github.com/gin-gonic/gin"
+)
+
+func (h Handler) response(ctx *gin.Context, statusCode int, msg string) {
+	ctx.JSON(statusCode, gin.H{
+		"message": msg,
+	})
+}
+
+// responseWithError maps gRPC status errors to their HTTP status and uses
+// statusCode only for errors that carry no usable gRPC code.
+func (h Handler) responseWithError(ctx *gin.Context, statusCode int, err error) {
+	common.ResponseWithError(ctx, statusCode, err)
+}
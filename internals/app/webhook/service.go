@@ -0,0 +1,162 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aparnasukesh/api-gateway/pkg/rabbitmq"
+	"github.com/aparnasukesh/inter-communication/payment"
+)
+
+var (
+	ErrDuplicateEvent = errors.New("event already processed")
+	ErrEventInFlight  = errors.New("event is still being processed")
+	ErrMalformedEvent = errors.New("event payload is missing its entity")
+)
+
+type Service interface {
+	HandleRazorpayEvent(ctx context.Context, event RazorpayEvent) error
+}
+
+type service struct {
+	paymentClient payment.PaymentServiceClient
+	dedupeTTL     time.Duration
+	events        *rabbitmq.Publisher
+
+	mu sync.Mutex
+	// seen holds event IDs that are processed or in flight, with the time
+	// they may be forgotten. Only processed IDs are reported as duplicates;
+	// in-flight ones are retried by Razorpay in case processing fails. It is local to the process, so the gateway
+	// must run as a single replica for deduplication to hold.
+	seen map[string]seenEvent
+}
+
+type seenEvent struct {
+	done    bool
+	expires time.Time
+}
+
+func NewService(paymentClient payment.PaymentServiceClient, dedupeTTL time.Duration, events *rabbitmq.Publisher) Service {
+	return &service{
+		paymentClient: paymentClient,
+		dedupeTTL:     dedupeTTL,
+		events:        events,
+		seen:          make(map[string]seenEvent),
+	}
+}
+
+// HandleRazorpayEvent forwards the event to payment-svc once per event ID.
+// A failed forward releases the ID so Razorpay's retry is processed.
+func (s *service) HandleRazorpayEvent(ctx context.Context, event RazorpayEvent) error {
+	if err := s.reserve(event.ID); err != nil {
+		return err
+	}
+	if err := s.dispatch(ctx, event); err != nil {
+		s.release(event.ID)
+		return err
+	}
+	s.finish(event.ID)
+	return nil
+}
+
+func (s *service) dispatch(ctx context.Context, event RazorpayEvent) error {
+	switch {
+	case event.Event == "payment.captured" || event.Event == "order.paid":
+		if event.Payload.Payment == nil {
+			return ErrMalformedEvent
+		}
+		entity := event.Payload.Payment.Entity
+		_, err := s.paymentClient.PaymentSuccess(ctx, &payment.PaymentSuccessRequest{
+			OrderId:           entity.OrderID,
+			RazorpayPaymentId: entity.ID,
+			BookingId:         entity.bookingID(),
+		})
+		if err != nil {
+			return err
+		}
+		s.events.Publish(rabbitmq.PaymentSucceeded{
+			OrderID:   entity.OrderID,
+			PaymentID: entity.ID,
+			BookingID: int(entity.bookingID()),
+			Source:    rabbitmq.SourceWebhook,
+		})
+		return nil
+	case event.Event == "payment.failed":
+		if event.Payload.Payment == nil {
+			return ErrMalformedEvent
+		}
+		entity := event.Payload.Payment.Entity
+		_, err := s.paymentClient.PaymentFailure(ctx, &payment.PaymentFailureRequest{
+			OrderId:           entity.OrderID,
+			RazorpayPaymentId: entity.ID,
+			BookingId:         entity.bookingID(),
+		})
+		if err != nil {
+			return err
+		}
+		s.events.Publish(rabbitmq.PaymentFailed{
+			OrderID:   entity.OrderID,
+			PaymentID: entity.ID,
+			BookingID: int(entity.bookingID()),
+			ErrorCode: entity.ErrorCode,
+			Reason:    entity.ErrorReason,
+			Source:    rabbitmq.SourceWebhook,
+		})
+		return nil
+	case strings.HasPrefix(event.Event, "refund."):
+		// payment-svc has no refund RPC yet, so refunds go to the domain
+		// events, which the outbox keeps until the broker confirms them.
+		if event.Payload.Refund == nil {
+			return ErrMalformedEvent
+		}
+		refund := event.Payload.Refund.Entity
+		s.events.Publish(rabbitmq.RefundUpdated{
+			RefundID:  refund.ID,
+			PaymentID: refund.PaymentID,
+			Amount:    refund.Amount,
+			Status:    refund.Status,
+			Trigger:   event.Event,
+			Source:    rabbitmq.SourceWebhook,
+		})
+		return nil
+	default:
+		log.Printf("razorpay event %s (%s) ignored", event.Event, event.ID)
+		return nil
+	}
+}
+
+func (s *service) reserve(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, seen := range s.seen {
+		if now.After(seen.expires) {
+			delete(s.seen, key)
+		}
+	}
+	if seen, ok := s.seen[id]; ok {
+		if seen.done {
+			return ErrDuplicateEvent
+		}
+		return ErrEventInFlight
+	}
+	s.seen[id] = seenEvent{expires: now.Add(s.dedupeTTL)}
+	return nil
+}
+
+func (s *service) finish(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seen[id] = seenEvent{done: true, expires: time.Now().Add(s.dedupeTTL)}
+}
+
+func (s *service) release(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.seen, id)
+}
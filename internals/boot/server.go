@@ -1,40 +1,142 @@
 package boot
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/aparnasukesh/api-gateway/config"
 	"github.com/aparnasukesh/api-gateway/internals/di"
+	"github.com/aparnasukesh/api-gateway/pkg/common"
+	grpcclient "github.com/aparnasukesh/api-gateway/pkg/grpcClient"
+	"github.com/aparnasukesh/api-gateway/pkg/idempotency"
+	"github.com/aparnasukesh/api-gateway/pkg/rabbitmq"
+	"github.com/aparnasukesh/api-gateway/pkg/ratelimit"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
 type resources struct {
-	cfg config.Config
+	cfg        config.Config
+	registry   *grpcclient.Registry
+	rabbitmq   *rabbitmq.Manager
+	events     *rabbitmq.Publisher
+	ready      atomic.Bool
+	onShutdown []func()
 }
 
 func Start(cfg config.Config) {
-	res := &resources{cfg: cfg}
+	res := &resources{
+		cfg:      cfg,
+		registry: grpcclient.NewRegistry(),
+		rabbitmq: rabbitmq.NewManager(cfg.RabbitMQURL, cfg.RabbitMQReconnectMin, cfg.RabbitMQReconnectMax),
+	}
 	r := gin.Default()
 	res.MountRoutes(r)
-	r.Run(":8080")
+	// The broker is dialled only after the routes registered their hooks.
+	// Until it is reachable, /readyz reports it as connecting and domain
+	// events wait in the outbox.
+	res.rabbitmq.Start()
+
+	srv := &http.Server{
+		Addr:    cfg.HttpAddr,
+		Handler: r,
+	}
+	for _, fn := range res.onShutdown {
+		srv.RegisterOnShutdown(fn)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+		close(serveErr)
+	}()
+	res.ready.Store(true)
+	log.Printf("api gateway listening on %s", cfg.HttpAddr)
+
+	select {
+	case <-ctx.Done():
+		log.Println("shutdown signal received, draining connections")
+	case err := <-serveErr:
+		log.Printf("http server stopped unexpectedly: %v", err)
+	}
+	stop()
+	res.shutdown(srv)
 }
 
-func (m resources) MountRoutes(r *gin.Engine) {
-	userHandler, err := di.InitUserModule(m.cfg)
+// shutdown flips readiness first so the load balancer stops routing new
+// traffic, then drains in-flight requests and closes upstream connections.
+func (m *resources) shutdown(srv *http.Server) {
+	m.ready.Store(false)
+	time.Sleep(m.cfg.ReadinessDrainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Error happened while draining http server: %v", err)
+	}
+
+	if err := m.registry.Close(); err != nil {
+		log.Printf("Error happened while closing grpc connections: %v", err)
+	}
+	if m.events != nil {
+		m.events.Close()
+	}
+	if err := m.rabbitmq.Close(); err != nil {
+		log.Printf("Error happened while closing rabbitmq connection: %v", err)
+	}
+	log.Println("api gateway stopped")
+}
+
+func (m *resources) MountRoutes(r *gin.Engine) {
+	limiter, err := di.InitRateLimiter(m.cfg)
+	if err != nil {
+		log.Fatalf("Error happened while rate limiter initialization: %v", err)
+	}
+	if m.cfg.TrustedProxies != "" {
+		if err := r.SetTrustedProxies(strings.Split(m.cfg.TrustedProxies, ",")); err != nil {
+			log.Fatalf("Error happened while setting trusted proxies: %v", err)
+		}
+	}
+	m.events, err = di.InitEventPublisher(m.cfg, m.rabbitmq)
+	if err != nil {
+		log.Fatalf("Error happened while event publisher initialization: %v", err)
+	}
+	userHandler, err := di.InitUserModule(m.cfg, m.registry, m.rabbitmq, m.events, limiter)
 	if err != nil {
 		log.Fatalf("Error happened while user module initialization: %v", err)
 	}
-	adminHandler, err := di.InitAdminModule(m.cfg)
+	adminHandler, err := di.InitAdminModule(m.cfg, m.registry, limiter)
 	if err != nil {
 		log.Fatalf("Error happened while admin module initialization: %v", err)
 	}
-	superAdminHandler, err := di.InitSuperAdminModule(m.cfg)
+	superAdminHandler, err := di.InitSuperAdminModule(m.cfg, m.registry, m.events, limiter)
 	if err != nil {
 		log.Fatalf("Error happend while super admin module initialization: %v", err)
 	}
+	webhookHandler, err := di.InitWebhookModule(m.cfg, m.registry, m.events)
+	if err != nil {
+		log.Fatalf("Error happened while webhook module initialization: %v", err)
+	}
+	m.onShutdown = append(m.onShutdown, userHandler.CloseChatSessions, userHandler.StartHoldExpiry(m.cfg.SeatHoldSweepInterval))
 
+	r.Use(common.RequestID())
 	r.Use(cors.New(SetCors()))
+	r.Use(limiter.Middleware())
+
+	r.GET("/healthz", m.liveness)
+	r.GET("/readyz", m.readiness)
 
 	gateway := r.Group("/gateway")
 	{
@@ -46,14 +148,50 @@ func (m resources) MountRoutes(r *gin.Engine) {
 
 		superAdmin := gateway.Group("/superadmin")
 		superAdminHandler.MountRoutes(superAdmin)
+
+		webhooks := gateway.Group("/webhooks")
+		webhookHandler.MountRoutes(webhooks)
 	}
 
 }
 
+func (m *resources) liveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+	})
+}
+
+func (m *resources) readiness(ctx *gin.Context) {
+	if !m.ready.Load() {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":    "not ready",
+			"upstreams": m.registry.States(),
+			"rabbitmq":  m.rabbitmqState(),
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":    "ready",
+		"upstreams": m.registry.States(),
+		"rabbitmq":  m.rabbitmqState(),
+	})
+}
+
+// rabbitmqState is reported but does not fail readiness: bookings keep
+// working while chat and seat events wait for the broker.
+func (m *resources) rabbitmqState() gin.H {
+	state, err := m.rabbitmq.State()
+	if err != nil {
+		return gin.H{"state": state, "error": err.Error()}
+	}
+	return gin.H{"state": state}
+}
+
 func SetCors() cors.Config {
 	return cors.Config{
 		AllowOrigins:     []string{"https://api.bookyourshow.com", "*"}, // Replace with actual Razorpay URL or use "*" to allow all
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", common.RequestIDHeader, ratelimit.APIKeyHeader, idempotency.KeyHeader},
+		ExposeHeaders:    []string{common.RequestIDHeader, "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After", idempotency.ReplayedHeader},
 		AllowCredentials: true,
 		AllowMethods:     []string{"POST", "GET", "PUT", "PATCH", "DELETE", "OPTION"},
 	}
@@ -1,95 +1,260 @@
 package di
 
 import (
+	"errors"
 	"log"
+	"strings"
 
 	"github.com/aparnasukesh/api-gateway/config"
 	"github.com/aparnasukesh/api-gateway/internals/app/admin"
 	"github.com/aparnasukesh/api-gateway/internals/app/middleware"
 	superadmin "github.com/aparnasukesh/api-gateway/internals/app/super-admin"
 	"github.com/aparnasukesh/api-gateway/internals/app/user"
+	"github.com/aparnasukesh/api-gateway/internals/app/webhook"
+	"github.com/aparnasukesh/api-gateway/pkg/cancellation"
 	"github.com/aparnasukesh/api-gateway/pkg/common"
 	grpcclient "github.com/aparnasukesh/api-gateway/pkg/grpcClient"
+	"github.com/aparnasukesh/api-gateway/pkg/helpdesk"
+	"github.com/aparnasukesh/api-gateway/pkg/idempotency"
+	"github.com/aparnasukesh/api-gateway/pkg/jwt"
+	"github.com/aparnasukesh/api-gateway/pkg/lockout"
+	"github.com/aparnasukesh/api-gateway/pkg/policy"
 	"github.com/aparnasukesh/api-gateway/pkg/rabbitmq"
+	"github.com/aparnasukesh/api-gateway/pkg/ratelimit"
+	"github.com/aparnasukesh/api-gateway/pkg/seathold"
+	"github.com/aparnasukesh/api-gateway/pkg/seatstream"
+	"github.com/aparnasukesh/api-gateway/pkg/ticket"
+	"github.com/aparnasukesh/api-gateway/pkg/transactions"
+	"github.com/streadway/amqp"
 )
 
-func InitUserModule(cfg config.Config) (*user.Handler, error) {
-	pb, err := grpcclient.NewUserGrpcClient(cfg.UserSvcPort)
+func InitUserModule(cfg config.Config, registry *grpcclient.Registry, broker *rabbitmq.Manager, events *rabbitmq.Publisher, limiter *ratelimit.Limiter) (*user.Handler, error) {
+	pb, err := grpcclient.NewUserGrpcClient(registry, cfg.UserSvc())
 	if err != nil {
 		return nil, err
 	}
-	authHandler, err := InitAuthMiddlewareModule(cfg)
+	authHandler, err := InitAuthMiddlewareModule(cfg, registry, limiter)
 	if err != nil {
-		log.Fatalf("Error happened while authmiddleware module initialization")
+		return nil, err
 	}
 
-	auth, err := grpcclient.NewJWT_TokenServiceClient(cfg.AuthSvcPort)
+	movieBooking, theater, booking, err := grpcclient.NewMovieBookingGrpcClint(registry, cfg.MovieBookingSvc())
 	if err != nil {
-		log.Fatalf("Error happened while TokenServiceClient module initialization")
+		return nil, err
 	}
-	movieBooking, theater, booking, err := grpcclient.NewMovieBookingGrpcClint(cfg.MovieBookingPort)
 
-	paymentClient, err := grpcclient.NewBookingPaymentServiceClient(cfg.PaymentPort)
-	rabbitmqConnection, err := rabbitmq.NewRabbitMQConnection()
+	paymentClient, err := grpcclient.NewBookingPaymentServiceClient(registry, cfg.PaymentSvc())
+	if err != nil {
+		return nil, err
+	}
+	seats := seatstream.NewHub(cfg.SeatStreamHistory)
+	err = broker.OnConnect("seat events", func(conn *amqp.Connection) error {
+		return seatstream.Consume(conn, cfg.SeatEventsExchange, cfg.SeatEventsBindingKey, seats)
+	})
 	if err != nil {
 		return nil, err
 	}
-	svc := user.NewService(pb, auth, movieBooking, theater, booking, paymentClient, rabbitmqConnection)
-	userHandler := user.NewHttpHandler(svc, authHandler)
+	var cancellations *cancellation.Policy
+	if cfg.CancellationFile != "" {
+		cancellations, err = cancellation.Load(cfg.CancellationFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var tickets *ticket.Signer
+	if cfg.TicketSigningKey != "" {
+		tickets, err = ticket.NewSigner(cfg.TicketSigningKey)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("ticket QR codes are verified with public key %s", tickets.PublicKey())
+	}
+	helpDesk := rabbitmq.NewRPCClient(cfg.HelpDeskQueue, cfg.HelpDeskTimeout)
+	if err := broker.OnConnect("help-desk rpc", helpDesk.Attach); err != nil {
+		return nil, err
+	}
+	svc := user.NewService(pb, movieBooking, theater, booking, paymentClient, cfg.RazorpayKeySecret, seathold.NewMemoryStore(), cfg.SeatHoldTTL, cfg.SeatHoldPaymentTTL, seats, cancellations, tickets, helpDesk, events, transactions.NewMemoryStore(), cfg.TransactionOwnerTTL)
+	idempotencyKeys := idempotency.NewKeys(idempotency.NewMemoryStore(), cfg.IdempotencyTTL)
+	chat := helpdesk.NewHub(cfg.HelpDeskExchange, cfg.HelpDeskInboxTTL, helpdesk.NewHistory(cfg.HelpDeskHistory))
+	if err := broker.OnConnect("help-desk chat", chat.Attach); err != nil {
+		return nil, err
+	}
+	userHandler := user.NewHttpHandler(svc, authHandler, lockout.NewGuard("user", loginLockoutConfig(cfg), nil), idempotencyKeys, seats, chat, chatSocketConfig(cfg))
 	return userHandler, nil
 }
 
-func InitAdminModule(cfg config.Config) (*admin.Handler, error) {
-	pb, err := grpcclient.NewAdminGrpcClient(cfg.UserSvcPort)
+// InitEventPublisher returns the domain event publisher attached to broker.
+// Close it before the broker so queued events reach the outbox.
+func InitEventPublisher(cfg config.Config, broker *rabbitmq.Manager) (*rabbitmq.Publisher, error) {
+	outbox, err := rabbitmq.OpenOutbox(cfg.EventsOutboxFile, cfg.EventsOutboxMaxAttempts)
 	if err != nil {
 		return nil, err
 	}
-	authHandler, err := InitAuthMiddlewareModule(cfg)
+	if n := outbox.Len(); n > 0 {
+		log.Printf("%d domain events waiting in %s", n, cfg.EventsOutboxFile)
+	}
+	events := rabbitmq.NewPublisher(rabbitmq.PublisherConfig{
+		Exchange:       cfg.EventsExchange,
+		Retries:        cfg.EventsRetries,
+		RetryBackoff:   cfg.EventsRetryBackoff,
+		ConfirmTimeout: cfg.EventsConfirmTimeout,
+		FlushInterval:  cfg.EventsFlushInterval,
+		QueueSize:      cfg.EventsQueueSize,
+	}, outbox)
+	if err := broker.OnConnect("domain events", events.Attach); err != nil {
+		events.Close()
+		return nil, err
+	}
+	return events, nil
+}
+
+func InitAdminModule(cfg config.Config, registry *grpcclient.Registry, limiter *ratelimit.Limiter) (*admin.Handler, error) {
+	pb, err := grpcclient.NewAdminGrpcClient(registry, cfg.UserSvc())
 	if err != nil {
-		log.Fatalf("Error happpened while authmiddleware module initialization")
+		return nil, err
 	}
-	auth, err := grpcclient.NewJWT_TokenServiceClient(cfg.AuthSvcPort)
+	authHandler, err := InitAuthMiddlewareModule(cfg, registry, limiter)
 	if err != nil {
-		log.Fatalf("Error happened while TokenServiceClient module initialization")
+		return nil, err
 	}
-	svc := admin.NewService(pb, auth)
-	adminHandler := admin.NewHttpHandler(svc, authHandler)
+	svc := admin.NewService(pb)
+	adminHandler := admin.NewHttpHandler(svc, authHandler, lockout.NewGuard("admin", loginLockoutConfig(cfg), nil))
 	return adminHandler, nil
 }
 
-func InitSuperAdminModule(cfg config.Config) (*superadmin.Handler, error) {
-	pb, err := grpcclient.NewSuperAdminServiceClient(cfg.UserSvcPort)
+func InitSuperAdminModule(cfg config.Config, registry *grpcclient.Registry, events *rabbitmq.Publisher, limiter *ratelimit.Limiter) (*superadmin.Handler, error) {
+	pb, err := grpcclient.NewSuperAdminServiceClient(registry, cfg.UserSvc())
 	if err != nil {
 		return nil, err
 	}
-	authHandler, err := InitAuthMiddlewareModule(cfg)
+	authHandler, err := InitAuthMiddlewareModule(cfg, registry, limiter)
 	if err != nil {
-		log.Fatalf("Error happpened while authmiddleware module initialization")
+		return nil, err
 	}
-	auth, err := grpcclient.NewJWT_TokenServiceClient(cfg.AuthSvcPort)
+	movieBooking, _, _, err := grpcclient.NewMovieBookingGrpcClint(registry, cfg.MovieBookingSvc())
 	if err != nil {
-		log.Fatalf("Error happened while TokenServiceClient module initialization")
+		return nil, err
 	}
-	movieBooking, _, _, err := grpcclient.NewMovieBookingGrpcClint(cfg.MovieBookingPort)
-	svc := superadmin.NewService(pb, auth, movieBooking)
-	adminHandler := superadmin.NewHttpHandler(svc, authHandler)
+	svc := superadmin.NewService(pb, movieBooking, events)
+	adminHandler := superadmin.NewHttpHandler(svc, authHandler, lockout.NewGuard("superadmin", loginLockoutConfig(cfg), nil))
 	return adminHandler, nil
 }
 
-func InitAuthMiddlewareModule(cfg config.Config) (common.Middleware, error) {
-	userSvcClient, err := grpcclient.NewUserAuthServiceClient(cfg.AuthSvcPort)
+func InitWebhookModule(cfg config.Config, registry *grpcclient.Registry, events *rabbitmq.Publisher) (*webhook.Handler, error) {
+	paymentClient, err := grpcclient.NewBookingPaymentServiceClient(registry, cfg.PaymentSvc())
+	if err != nil {
+		return nil, err
+	}
+	svc := webhook.NewService(paymentClient, cfg.RazorpayWebhookDedupeTTL, events)
+	webhookHandler := webhook.NewHttpHandler(svc, cfg.RazorpayWebhookSecret)
+	return webhookHandler, nil
+}
+
+func InitAuthMiddlewareModule(cfg config.Config, registry *grpcclient.Registry, limiter *ratelimit.Limiter) (common.Middleware, error) {
+	userSvcClient, err := grpcclient.NewUserAuthServiceClient(registry, cfg.AuthSvc())
+	if err != nil {
+		return nil, err
+	}
+	adminSvcClient, err := grpcclient.NewAdminAuthServiceClient(registry, cfg.AuthSvc())
 	if err != nil {
 		return nil, err
 	}
-	adminSvcClient, err := grpcclient.NewAdminAuthServiceClient(cfg.AuthSvcPort)
+	superAdminSvcClient, err := grpcclient.NewSuperAdminAuthServiceClient(registry, cfg.AuthSvc())
 	if err != nil {
 		return nil, err
 	}
-	superAdminSvcClient, err := grpcclient.NewSuperAdminAuthServiceClient(cfg.AuthSvcPort)
+	tokenSvcClient, err := grpcclient.NewJWT_TokenServiceClient(registry, cfg.AuthSvc())
 	if err != nil {
 		return nil, err
 	}
-	svc := middleware.NewService(userSvcClient, adminSvcClient, superAdminSvcClient)
-	middlewareHandler := middleware.NewHttpHandler(svc)
+	claims := middleware.ClaimNames{
+		Role:       cfg.JWTRoleClaim,
+		UserID:     cfg.JWTUserIDClaim,
+		Email:      cfg.JWTEmailClaim,
+		User:       cfg.JWTUserRole,
+		Admin:      cfg.JWTAdminRole,
+		SuperAdmin: cfg.JWTSuperAdminRole,
+	}
+	svc := middleware.NewService(userSvcClient, adminSvcClient, superAdminSvcClient, tokenSvcClient, claims)
+	if cfg.JWTMode != "remote" {
+		keys, err := newJWTKeySource(cfg)
+		if err != nil {
+			return nil, err
+		}
+		var remote middleware.Service
+		if cfg.JWTMode == "hybrid" {
+			remote = svc
+		}
+		verifier := jwt.NewVerifier(keys, cfg.JWTIssuer, cfg.JWTLeeway)
+		svc = middleware.NewLocalService(verifier, claims, remote)
+	}
+	var routePolicy *policy.Policy
+	if cfg.PolicyFile != "" {
+		routePolicy, err = policy.Load(cfg.PolicyFile)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.PolicyDryRun != nil {
+			routePolicy.DryRun = *cfg.PolicyDryRun
+		}
+		if routePolicy.DryRun {
+			log.Printf("route policy %s is in dry-run mode: denials are logged, not enforced", cfg.PolicyFile)
+		}
+	}
+	middlewareHandler := middleware.NewHttpHandler(svc, routePolicy, limiter)
 	return middlewareHandler, nil
 }
+
+// InitRateLimiter returns nil, which disables rate limiting, when no
+// RateLimitFile is configured.
+func InitRateLimiter(cfg config.Config) (*ratelimit.Limiter, error) {
+	if cfg.RateLimitFile == "" {
+		return nil, nil
+	}
+	rules, err := ratelimit.LoadRules(cfg.RateLimitFile)
+	if err != nil {
+		return nil, err
+	}
+	var apiKeys []string
+	if cfg.RateLimitAPIKeys != "" {
+		apiKeys = strings.Split(cfg.RateLimitAPIKeys, ",")
+	}
+	return ratelimit.NewLimiter(ratelimit.NewMemoryStore(), rules, apiKeys), nil
+}
+
+func loginLockoutConfig(cfg config.Config) lockout.Config {
+	return lockout.Config{
+		FreeAttempts:     cfg.LoginFreeAttempts,
+		BackoffBase:      cfg.LoginBackoffBase,
+		BackoffMax:       cfg.LoginBackoffMax,
+		AccountThreshold: cfg.LoginLockoutThreshold,
+		IPThreshold:      cfg.LoginIPLockoutThreshold,
+		LockoutDuration:  cfg.LoginLockoutDuration,
+		FailureWindow:    cfg.LoginFailureWindow,
+	}
+}
+
+func chatSocketConfig(cfg config.Config) user.ChatSocketConfig {
+	sockets := user.ChatSocketConfig{
+		PingInterval:    cfg.ChatPingInterval,
+		IdleTimeout:     cfg.ChatIdleTimeout,
+		MaxMessageSize:  cfg.ChatMaxMessageSize,
+		MaxConnsPerUser: cfg.ChatMaxConnsPerUser,
+	}
+	if cfg.ChatAllowedOrigins != "" {
+		sockets.AllowedOrigins = strings.Split(cfg.ChatAllowedOrigins, ",")
+	}
+	return sockets
+}
+
+func newJWTKeySource(cfg config.Config) (jwt.KeySource, error) {
+	switch {
+	case cfg.JWTSecret != "":
+		return jwt.NewSecretKeySource(cfg.JWTSecret), nil
+	case cfg.JWTJWKSFile != "":
+		return jwt.NewJWKSFileKeySource(cfg.JWTJWKSFile)
+	case cfg.JWTJWKSURL != "":
+		return jwt.NewJWKSURLKeySource(cfg.JWTJWKSURL, cfg.JWTJWKSRefresh), nil
+	}
+	return nil, errors.New("JWTMode requires JWTSecret, JWTJWKSFile or JWTJWKSURL")
+}
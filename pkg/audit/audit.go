@@ -0,0 +1,38 @@
+package audit
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/aparnasukesh/api-gateway/pkg/common"
+	"github.com/gin-gonic/gin"
+)
+
+// Entry is one audit record. Entries are written to the standard logger as
+// single-line JSON prefixed with "audit:" so they can be filtered out.
+type Entry struct {
+	Time      time.Time         `json:"time"`
+	Event     string            `json:"event"`
+	RequestID string            `json:"request_id,omitempty"`
+	UserID    int               `json:"user_id,omitempty"`
+	IP        string            `json:"ip,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+}
+
+// Record writes an audit entry for the request, including the caller when
+// one is authenticated.
+func Record(ctx *gin.Context, event string, fields map[string]string) {
+	entry := Entry{
+		Time:      time.Now().UTC(),
+		Event:     event,
+		RequestID: common.GetRequestID(ctx),
+		IP:        ctx.ClientIP(),
+		Fields:    fields,
+	}
+	if principal, ok := common.GetPrincipal(ctx); ok && principal.Resolve() == nil {
+		entry.UserID = principal.UserID
+	}
+	data, _ := json.Marshal(entry)
+	log.Printf("audit: %s", data)
+}
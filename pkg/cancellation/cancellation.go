@@ -0,0 +1,113 @@
+package cancellation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var ErrWindowClosed = errors.New("booking can no longer be cancelled")
+
+// Fee is charged when a booking is cancelled less than Within before the
+// show. Percent is of the booking total; Flat is added on top.
+type Fee struct {
+	Within  time.Duration `yaml:"within"`
+	Percent float64       `yaml:"percent"`
+	Flat    float64       `yaml:"flat"`
+}
+
+// Rule decides whether and at what cost a booking can be cancelled.
+// Cancelling is refused less than Cutoff before the show. Of the fees whose
+// Within is still ahead, the one closest to the show applies.
+type Rule struct {
+	Cutoff time.Duration `yaml:"cutoff"`
+	Fees   []Fee         `yaml:"fees"`
+}
+
+// Policy is the cancellation file. Theaters without their own rule use
+// Default.
+//
+//	default:
+//	  cutoff: 2h
+//	  fees:
+//	    - {within: 24h, percent: 10}
+//	    - {within: 6h, percent: 50}
+//	theaters:
+//	  3: {cutoff: 4h}
+type Policy struct {
+	Default  Rule         `yaml:"default"`
+	Theaters map[int]Rule `yaml:"theaters"`
+}
+
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	policy := &Policy{}
+	if err := yaml.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("cancellation rules %s: %w", path, err)
+	}
+	if err := policy.Default.validate(); err != nil {
+		return nil, fmt.Errorf("cancellation rules %s: default: %w", path, err)
+	}
+	for id, rule := range policy.Theaters {
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("cancellation rules %s: theater %d: %w", path, id, err)
+		}
+		policy.Theaters[id] = rule
+	}
+	return policy, nil
+}
+
+// For returns the rule of the theater. A nil Policy allows cancelling
+// without a fee until the show starts.
+func (p *Policy) For(theaterID int) Rule {
+	if p == nil {
+		return Rule{}
+	}
+	if rule, ok := p.Theaters[theaterID]; ok {
+		return rule
+	}
+	return p.Default
+}
+
+// Quote returns the fee for cancelling a booking of total, with until left
+// before the show. The fee never exceeds the total and is rounded to paise.
+func (r Rule) Quote(total float64, until time.Duration) (float64, error) {
+	if until <= 0 || until < r.Cutoff {
+		return 0, ErrWindowClosed
+	}
+	fee := 0.0
+	for _, f := range r.Fees {
+		if until < f.Within {
+			fee = total*f.Percent/100 + f.Flat
+			break
+		}
+	}
+	fee = math.Min(math.Round(fee*100)/100, total)
+	return fee, nil
+}
+
+func (r *Rule) validate() error {
+	if r.Cutoff < 0 {
+		return fmt.Errorf("cutoff must not be negative")
+	}
+	for _, f := range r.Fees {
+		if f.Within <= 0 {
+			return fmt.Errorf("fee within must be positive")
+		}
+		if f.Percent < 0 || f.Percent > 100 || f.Flat < 0 {
+			return fmt.Errorf("fee percent must be within 0-100 and flat must not be negative")
+		}
+	}
+	sort.Slice(r.Fees, func(i, j int) bool {
+		return r.Fees[i].Within < r.Fees[j].Within
+	})
+	return nil
+}
@@ -0,0 +1,96 @@
+package common
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorBody is the JSON body written for every failed request.
+type ErrorBody struct {
+	Error     string `json:"error"`
+	Code      string `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+type grpcMapping struct {
+	status int
+	code   string
+}
+
+var grpcCodes = map[codes.Code]grpcMapping{
+	codes.Canceled:           {499, "CANCELLED"},
+	codes.InvalidArgument:    {http.StatusBadRequest, "INVALID_ARGUMENT"},
+	codes.DeadlineExceeded:   {http.StatusGatewayTimeout, "DEADLINE_EXCEEDED"},
+	codes.NotFound:           {http.StatusNotFound, "NOT_FOUND"},
+	codes.AlreadyExists:      {http.StatusConflict, "ALREADY_EXISTS"},
+	codes.PermissionDenied:   {http.StatusForbidden, "PERMISSION_DENIED"},
+	codes.ResourceExhausted:  {http.StatusTooManyRequests, "RESOURCE_EXHAUSTED"},
+	codes.FailedPrecondition: {http.StatusBadRequest, "FAILED_PRECONDITION"},
+	codes.Aborted:            {http.StatusConflict, "ABORTED"},
+	codes.OutOfRange:         {http.StatusBadRequest, "OUT_OF_RANGE"},
+	codes.Unimplemented:      {http.StatusNotImplemented, "UNIMPLEMENTED"},
+	codes.Internal:           {http.StatusInternalServerError, "INTERNAL"},
+	codes.Unavailable:        {http.StatusServiceUnavailable, "UNAVAILABLE"},
+	codes.DataLoss:           {http.StatusInternalServerError, "DATA_LOSS"},
+	codes.Unauthenticated:    {http.StatusUnauthorized, "UNAUTHENTICATED"},
+}
+
+var httpCodes = map[int]string{
+	http.StatusBadRequest:          "BAD_REQUEST",
+	http.StatusUnauthorized:        "UNAUTHENTICATED",
+	http.StatusForbidden:           "PERMISSION_DENIED",
+	http.StatusNotFound:            "NOT_FOUND",
+	http.StatusConflict:            "CONFLICT",
+	http.StatusLocked:              "LOCKED",
+	http.StatusTooManyRequests:     "RATE_LIMITED",
+	http.StatusInternalServerError: "INTERNAL",
+	http.StatusNotImplemented:      "UNIMPLEMENTED",
+	http.StatusBadGateway:          "BAD_GATEWAY",
+	http.StatusServiceUnavailable:  "UNAVAILABLE",
+	http.StatusGatewayTimeout:      "DEADLINE_EXCEEDED",
+}
+
+// notFoundMessage is what the backends return, with codes.Unknown, when a
+// gorm lookup finds nothing.
+const notFoundMessage = "record not found"
+
+// TranslateError resolves the HTTP status, error code and message for err.
+// gRPC status errors are mapped by their code; anything else, including
+// codes.Unknown from backends that do not set codes, falls back to
+// fallbackStatus.
+func TranslateError(fallbackStatus int, err error) (int, string, string) {
+	st, ok := status.FromError(err)
+	if !ok {
+		return fallbackStatus, httpCode(fallbackStatus), err.Error()
+	}
+	if mapping, found := grpcCodes[st.Code()]; found {
+		return mapping.status, mapping.code, st.Message()
+	}
+	if st.Message() == notFoundMessage {
+		return http.StatusNotFound, "NOT_FOUND", st.Message()
+	}
+	return fallbackStatus, httpCode(fallbackStatus), st.Message()
+}
+
+// ResponseWithError writes the translated error body and aborts the chain.
+func ResponseWithError(ctx *gin.Context, fallbackStatus int, err error) {
+	statusCode, code, msg := TranslateError(fallbackStatus, err)
+	ctx.AbortWithStatusJSON(statusCode, ErrorBody{
+		Error:     msg,
+		Code:      code,
+		RequestID: GetRequestID(ctx),
+	})
+}
+
+func httpCode(statusCode int) string {
+	if code, ok := httpCodes[statusCode]; ok {
+		return code
+	}
+	if statusCode >= http.StatusInternalServerError {
+		return "INTERNAL"
+	}
+	return "BAD_REQUEST"
+}
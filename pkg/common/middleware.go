@@ -7,4 +7,6 @@ type Middleware interface {
 	AdminAuthMiddleware() gin.HandlerFunc
 	SuperAdminAuthMiddleware() gin.HandlerFunc
 	UserPaymentAuthorization() gin.HandlerFunc
+	Authorize() gin.HandlerFunc
+	RateLimit() gin.HandlerFunc
 }
@@ -0,0 +1,62 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrNotOwner is returned when the caller does not own the requested resource.
+var ErrNotOwner = errors.New("forbidden: resource belongs to another user")
+
+// OwnerResolver returns the ID of the user owning the resource identified by id.
+// Resolvers that cannot determine an owner should return ErrNotOwner.
+type OwnerResolver func(ctx context.Context, id int) (int, error)
+
+// SelfOwned is the resolver for routes where the path ID is the user ID itself.
+func SelfOwned(ctx context.Context, id int) (int, error) {
+	return id, nil
+}
+
+// Privileged reports whether the principal may act on other users' resources.
+func (p *Principal) Privileged() bool {
+	return p.Role == RoleAdmin || p.Role == RoleSuperAdmin
+}
+
+// RequireOwnership rejects the request with 403 unless the resource named by
+// the path parameter belongs to the authenticated user. Privileged principals
+// are let through without resolving the owner.
+func RequireOwnership(param string, resolve OwnerResolver) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		principal, ok := RequirePrincipal(ctx)
+		if !ok {
+			return
+		}
+		if principal.Privileged() {
+			ctx.Next()
+			return
+		}
+		id, err := strconv.Atoi(ctx.Param(param))
+		if err != nil {
+			ResponseWithError(ctx, http.StatusBadRequest, err)
+			return
+		}
+		owner, err := resolve(ctx, id)
+		if errors.Is(err, ErrNotOwner) {
+			ResponseWithError(ctx, http.StatusForbidden, err)
+			return
+		}
+		if err != nil {
+			ResponseWithError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		if owner != principal.UserID {
+			ResponseWithError(ctx, http.StatusForbidden, ErrNotOwner)
+			return
+		}
+		ctx.Next()
+	}
+}
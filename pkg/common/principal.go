@@ -0,0 +1,83 @@
+package common
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Gateway roles, independent of how the auth service encodes them in tokens.
+const (
+	RoleUser       = "user"
+	RoleAdmin      = "admin"
+	RoleSuperAdmin = "superadmin"
+)
+
+const principalKey = "principal"
+
+// Principal is the authenticated caller resolved once by the auth middleware.
+type Principal struct {
+	UserID    int
+	Role      string
+	Email     string
+	ExpiresAt time.Time
+
+	// resolve looks UserID up on first use when the token was accepted by a
+	// check that does not return it.
+	resolve     func() (int, error)
+	resolveOnce sync.Once
+	resolveErr  error
+}
+
+// NewDeferredPrincipal returns a principal whose UserID is filled in by
+// resolve the first time Resolve or RequirePrincipal needs it.
+func NewDeferredPrincipal(role string, resolve func() (int, error)) *Principal {
+	return &Principal{
+		Role:    role,
+		resolve: resolve,
+	}
+}
+
+// Resolve fills in UserID of a deferred principal. It is a no-op for
+// principals built with a known ID.
+func (p *Principal) Resolve() error {
+	if p.resolve == nil {
+		return nil
+	}
+	p.resolveOnce.Do(func() {
+		p.UserID, p.resolveErr = p.resolve()
+	})
+	return p.resolveErr
+}
+
+func SetPrincipal(ctx *gin.Context, principal *Principal) {
+	ctx.Set(principalKey, principal)
+}
+
+func GetPrincipal(ctx *gin.Context) (*Principal, bool) {
+	value, ok := ctx.Get(principalKey)
+	if !ok {
+		return nil, false
+	}
+	principal, ok := value.(*Principal)
+	return principal, ok && principal != nil
+}
+
+// RequirePrincipal returns the authenticated caller with its UserID
+// resolved or writes an error and reports false, so handlers only need to
+// return.
+func RequirePrincipal(ctx *gin.Context) (*Principal, bool) {
+	principal, ok := GetPrincipal(ctx)
+	if !ok {
+		ResponseWithError(ctx, http.StatusUnauthorized, errors.New("unauthorized: request is not authenticated"))
+		return nil, false
+	}
+	if err := principal.Resolve(); err != nil {
+		ResponseWithError(ctx, http.StatusUnauthorized, err)
+		return nil, false
+	}
+	return principal, true
+}
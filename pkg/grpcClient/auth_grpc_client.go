@@ -1,10 +1,8 @@
 package grpcclient
 
 import (
-	"log"
-
+	"github.com/aparnasukesh/api-gateway/config"
 	pb "github.com/aparnasukesh/inter-communication/auth"
-	"google.golang.org/grpc"
 )
 
 // func NewJWT_TokenServiceClient(port string) (pb.JWT_TokenServiceClient, error) {
@@ -15,45 +13,33 @@ import (
 // 	return pb.NewJWT_TokenServiceClient(conn), nil
 // }
 
-func NewJWT_TokenServiceClient(port string) (pb.JWT_TokenServiceClient, error) {
-	address := "auth-svc.default.svc.cluster.local:" + port
-	serviceConfig := `{"loadBalancingPolicy": "round_robin"}`
-	conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithDefaultServiceConfig(serviceConfig))
+func NewJWT_TokenServiceClient(registry *Registry, backend config.Backend) (pb.JWT_TokenServiceClient, error) {
+	conn, err := registry.Conn(backend)
 	if err != nil {
-		log.Printf("Failed to connect to gRPC service: %v", err)
 		return nil, err
 	}
 	return pb.NewJWT_TokenServiceClient(conn), nil
 }
 
-func NewUserAuthServiceClient(port string) (pb.UserAuthServiceClient, error) {
-	address := "auth-svc.default.svc.cluster.local:" + port
-	serviceConfig := `{"loadBalancingPolicy": "round_robin"}`
-	conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithDefaultServiceConfig(serviceConfig))
+func NewUserAuthServiceClient(registry *Registry, backend config.Backend) (pb.UserAuthServiceClient, error) {
+	conn, err := registry.Conn(backend)
 	if err != nil {
-		log.Printf("Failed to connect to gRPC service: %v", err)
 		return nil, err
 	}
 	return pb.NewUserAuthServiceClient(conn), nil
 }
 
-func NewAdminAuthServiceClient(port string) (pb.AdminAuthServiceClient, error) {
-	address := "auth-svc.default.svc.cluster.local:" + port
-	serviceConfig := `{"loadBalancingPolicy": "round_robin"}`
-	conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithDefaultServiceConfig(serviceConfig))
+func NewAdminAuthServiceClient(registry *Registry, backend config.Backend) (pb.AdminAuthServiceClient, error) {
+	conn, err := registry.Conn(backend)
 	if err != nil {
-		log.Printf("Failed to connect to gRPC service: %v", err)
 		return nil, err
 	}
 	return pb.NewAdminAuthServiceClient(conn), nil
 }
 
-func NewSuperAdminAuthServiceClient(port string) (pb.SuperAdminAuthServiceClient, error) {
-	address := "auth-svc.default.svc.cluster.local:" + port
-	serviceConfig := `{"loadBalancingPolicy": "round_robin"}`
-	conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithDefaultServiceConfig(serviceConfig))
+func NewSuperAdminAuthServiceClient(registry *Registry, backend config.Backend) (pb.SuperAdminAuthServiceClient, error) {
+	conn, err := registry.Conn(backend)
 	if err != nil {
-		log.Printf("Failed to connect to gRPC service: %v", err)
 		return nil, err
 	}
 	return pb.NewSuperAdminAuthServiceClient(conn), nil
@@ -0,0 +1,86 @@
+package grpcclient
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/aparnasukesh/api-gateway/config"
+	"github.com/aparnasukesh/api-gateway/pkg/common"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const serviceConfig = `{"loadBalancingPolicy": "round_robin"}`
+
+// Registry hands out one shared client connection per backend target so
+// every service client talking to the same backend reuses a single channel.
+type Registry struct {
+	mu    sync.Mutex
+	conns map[string]*grpc.ClientConn
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		conns: map[string]*grpc.ClientConn{},
+	}
+}
+
+// Conn returns the connection for the backend target, dialing it on first use.
+func (r *Registry) Conn(backend config.Backend) (*grpc.ClientConn, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if conn, ok := r.conns[backend.Target]; ok {
+		return conn, nil
+	}
+	creds, err := transportCredentials(backend)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.Dial(backend.Target,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+		grpc.WithUnaryInterceptor(requestIDInterceptor),
+	)
+	if err != nil {
+		log.Printf("Failed to connect to gRPC service: %v", err)
+		return nil, err
+	}
+	r.conns[backend.Target] = conn
+	return conn, nil
+}
+
+// States reports the connectivity state of every registered target.
+func (r *Registry) States() map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	states := make(map[string]string, len(r.conns))
+	for target, conn := range r.conns {
+		states[target] = conn.GetState().String()
+	}
+	return states
+}
+
+// Close closes every registered connection.
+func (r *Registry) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var errs []error
+	for target, conn := range r.conns {
+		if err := conn.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(r.conns, target)
+	}
+	return errors.Join(errs...)
+}
+
+// requestIDInterceptor forwards the gateway request ID to the backends. Handlers
+// pass *gin.Context as the call context, which resolves string keys.
+func requestIDInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if id, ok := ctx.Value(common.RequestIDKey).(string); ok && id != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-request-id", id)
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
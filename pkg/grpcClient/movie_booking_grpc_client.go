@@ -1,20 +1,13 @@
 package grpcclient
 
 import (
-	"log"
-
+	"github.com/aparnasukesh/api-gateway/config"
 	pb "github.com/aparnasukesh/inter-communication/movie_booking"
-	"google.golang.org/grpc"
 )
 
-func NewMovieBookingGrpcClint(port string) (pb.MovieServiceClient, pb.TheatreServiceClient, pb.BookingServiceClient, error) {
-
-	address := "movies-booking-svc.default.svc.cluster.local:" + port
-
-	serviceConfig := `{"loadBalancingPolicy": "round_robin"}`
-	conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithDefaultServiceConfig(serviceConfig))
+func NewMovieBookingGrpcClint(registry *Registry, backend config.Backend) (pb.MovieServiceClient, pb.TheatreServiceClient, pb.BookingServiceClient, error) {
+	conn, err := registry.Conn(backend)
 	if err != nil {
-		log.Printf("Failed to connect to gRPC service: %v", err)
 		return nil, nil, nil, err
 	}
 	return pb.NewMovieServiceClient(conn), pb.NewTheatreServiceClient(conn), pb.NewBookingServiceClient(conn), nil
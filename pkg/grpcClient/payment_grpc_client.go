@@ -1,10 +1,8 @@
 package grpcclient
 
 import (
-	"log"
-
+	"github.com/aparnasukesh/api-gateway/config"
 	pb "github.com/aparnasukesh/inter-communication/payment"
-	"google.golang.org/grpc"
 )
 
 // func NewBookingPaymentServiceClient(port string) (pb.PaymentServiceClient, error) {
@@ -15,13 +13,9 @@ import (
 // 	return pb.NewPaymentServiceClient(conn), nil
 // }
 
-func NewBookingPaymentServiceClient(port string) (pb.PaymentServiceClient, error) {
-
-	address := "payment-svc.default.svc.cluster.local:" + port
-	serviceConfig := `{"loadBalancingPolicy": "round_robin"}`
-	conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithDefaultServiceConfig(serviceConfig))
+func NewBookingPaymentServiceClient(registry *Registry, backend config.Backend) (pb.PaymentServiceClient, error) {
+	conn, err := registry.Conn(backend)
 	if err != nil {
-		log.Printf("Failed to connect to gRPC service: %v", err)
 		return nil, err
 	}
 	return pb.NewPaymentServiceClient(conn), nil
@@ -1,10 +1,8 @@
 package grpcclient
 
 import (
-	"log"
-
+	"github.com/aparnasukesh/api-gateway/config"
 	pb "github.com/aparnasukesh/inter-communication/user_admin"
-	"google.golang.org/grpc"
 )
 
 // func NewUserGrpcClient(port string) (pb.UserServiceClient, error) {
@@ -15,35 +13,25 @@ import (
 // 	return pb.NewUserServiceClient(conn), nil
 // }
 
-func NewUserGrpcClient(port string) (pb.UserServiceClient, error) {
-	address := "user-admin-svc.default.svc.cluster.local:" + port
-	serviceConfig := `{"loadBalancingPolicy": "round_robin"}`
-	conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithDefaultServiceConfig(serviceConfig))
+func NewUserGrpcClient(registry *Registry, backend config.Backend) (pb.UserServiceClient, error) {
+	conn, err := registry.Conn(backend)
 	if err != nil {
-		log.Printf("Failed to connect to gRPC service: %v", err)
 		return nil, err
 	}
-
 	return pb.NewUserServiceClient(conn), nil
 }
 
-func NewAdminGrpcClient(port string) (pb.AdminServiceClient, error) {
-	address := "user-admin-svc.default.svc.cluster.local:" + port
-	serviceConfig := `{"loadBalancingPolicy": "round_robin"}`
-	conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithDefaultServiceConfig(serviceConfig))
+func NewAdminGrpcClient(registry *Registry, backend config.Backend) (pb.AdminServiceClient, error) {
+	conn, err := registry.Conn(backend)
 	if err != nil {
-		log.Printf("Failed to connect to gRPC service: %v", err)
 		return nil, err
 	}
 	return pb.NewAdminServiceClient(conn), nil
 }
 
-func NewSuperAdminServiceClient(port string) (pb.SuperAdminServiceClient, error) {
-	address := "user-admin-svc.default.svc.cluster.local:" + port
-	serviceConfig := `{"loadBalancingPolicy": "round_robin"}`
-	conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithDefaultServiceConfig(serviceConfig))
+func NewSuperAdminServiceClient(registry *Registry, backend config.Backend) (pb.SuperAdminServiceClient, error) {
+	conn, err := registry.Conn(backend)
 	if err != nil {
-		log.Printf("Failed to connect to gRPC service: %v", err)
 		return nil, err
 	}
 	return pb.NewSuperAdminServiceClient(conn), nil
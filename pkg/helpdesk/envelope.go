@@ -0,0 +1,47 @@
+package helpdesk
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// Envelope types sent over the chat socket and the agent exchange.
+const (
+	TypeMessage      = "message"
+	TypeTyping       = "typing"
+	TypeTicketStatus = "ticket_status"
+	TypeAck          = "ack"
+	TypeError        = "error"
+)
+
+// Senders of an envelope.
+const (
+	FromUser   = "user"
+	FromAgent  = "agent"
+	FromSystem = "system"
+)
+
+// Envelope is one chat frame. Messages carry an ID the receiver acknowledges
+// with {"type": "ack", "id": ...}; Replay marks frames resent from history
+// on reconnect. The gateway assigns the ID of user messages itself; Ref
+// echoes the ID the client sent on the ack or error answering its frame.
+type Envelope struct {
+	ID     string    `json:"id,omitempty"`
+	Ref    string    `json:"ref,omitempty"`
+	Type   string    `json:"type"`
+	From   string    `json:"from,omitempty"`
+	UserID int       `json:"user_id,omitempty"`
+	Body   string    `json:"body,omitempty"`
+	Status string    `json:"status,omitempty"`
+	SentAt time.Time `json:"sent_at"`
+	Replay bool      `json:"replay,omitempty"`
+}
+
+// NewID returns an ID that sorts by creation time.
+func NewID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return strconv.FormatInt(time.Now().UnixNano(), 36) + "-" + hex.EncodeToString(b)
+}
@@ -0,0 +1,54 @@
+package helpdesk
+
+import "sync"
+
+// History keeps the latest messages of every conversation so a reconnecting
+// socket can catch up. It is local to one gateway process.
+type History struct {
+	mu    sync.Mutex
+	size  int
+	users map[int][]Envelope
+}
+
+func NewHistory(size int) *History {
+	return &History{
+		size:  size,
+		users: make(map[int][]Envelope),
+	}
+}
+
+// Add records a message unless one with the same ID is already kept, which
+// happens when the broker redelivers an unacknowledged message. It reports
+// whether the message was new.
+func (h *History) Add(userID int, env Envelope) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	messages := h.users[userID]
+	for _, kept := range messages {
+		if kept.ID == env.ID {
+			return false
+		}
+	}
+	messages = append(messages, env)
+	if len(messages) > h.size {
+		messages = messages[len(messages)-h.size:]
+	}
+	h.users[userID] = messages
+	return true
+}
+
+// Since returns the messages after the one with ID since, or every kept
+// message when since is empty or no longer kept.
+func (h *History) Since(userID int, since string) []Envelope {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	messages := h.users[userID]
+	start := 0
+	for i, kept := range messages {
+		if kept.ID == since {
+			start = i + 1
+			break
+		}
+	}
+	return append([]Envelope(nil), messages[start:]...)
+}
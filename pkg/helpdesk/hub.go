@@ -0,0 +1,366 @@
+package helpdesk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aparnasukesh/api-gateway/pkg/rabbitmq"
+	"github.com/streadway/amqp"
+)
+
+const (
+	sessionBuffer = 32
+	// defaultInboxTTL applies when the configured TTL cannot be used as a
+	// queue's x-expires, which must be 1ms to MaxInt32 ms.
+	defaultInboxTTL = 24 * time.Hour
+	maxInboxTTL     = math.MaxInt32 * time.Millisecond
+)
+
+// Hub connects chat sockets to the help-desk exchange. Agents publish to
+// routing key "user.<id>" and receive what users send on "agent.<id>".
+// Each user has a queue that outlives the socket, so agent messages sent
+// while the user is away are delivered on the next connect. A message is
+// acknowledged to the broker once one of the user's sockets acks it.
+// Attach the hub to every new connection with rabbitmq.Manager.OnConnect.
+type Hub struct {
+	exchange string
+	inboxTTL time.Duration
+	history  *History
+
+	publishMu sync.Mutex
+	publishCh *amqp.Channel
+
+	mu      sync.Mutex
+	conn    *amqp.Connection
+	inboxes map[int]*inbox
+	// opening holds a channel per user whose inbox is being opened, closed
+	// when it is done, so the broker round-trips run without h.mu.
+	opening map[int]chan struct{}
+}
+
+type inbox struct {
+	ch       *amqp.Channel
+	sessions map[*Session]struct{}
+	// pending maps message IDs to unacknowledged delivery tags.
+	pending map[string]uint64
+}
+
+// Session is one socket of a user. Out is closed when the session leaves or
+// falls too far behind.
+type Session struct {
+	Out    chan Envelope
+	userID int
+	hub    *Hub
+	closed bool
+}
+
+func NewHub(exchange string, inboxTTL time.Duration, history *History) *Hub {
+	switch {
+	case inboxTTL < time.Millisecond:
+		log.Printf("help-desk inbox TTL %s is too short, using %s", inboxTTL, defaultInboxTTL)
+		inboxTTL = defaultInboxTTL
+	case inboxTTL > maxInboxTTL:
+		log.Printf("help-desk inbox TTL %s is too long, using %s", inboxTTL, maxInboxTTL)
+		inboxTTL = maxInboxTTL
+	}
+	return &Hub{
+		exchange: exchange,
+		inboxTTL: inboxTTL,
+		history:  history,
+		inboxes:  make(map[int]*inbox),
+		opening:  make(map[int]chan struct{}),
+	}
+}
+
+// Attach declares the exchange on conn and uses conn for new inboxes.
+// Inboxes on a previous connection end with it, which closes their sessions
+// so clients reconnect.
+func (h *Hub) Attach(conn *amqp.Connection) error {
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	if err := ch.ExchangeDeclare(h.exchange, "topic", true, false, false, false, nil); err != nil {
+		ch.Close()
+		return err
+	}
+	h.publishMu.Lock()
+	h.publishCh = ch
+	h.publishMu.Unlock()
+	h.mu.Lock()
+	h.conn = conn
+	h.mu.Unlock()
+	return nil
+}
+
+// Join registers a socket of the user, opening the user's queue if this is
+// the first one on this gateway. Concurrent joins of the same user wait for
+// one inbox instead of each declaring a consumer.
+func (h *Hub) Join(userID int) (*Session, error) {
+	h.mu.Lock()
+	in, ok := h.inboxes[userID]
+	for !ok {
+		if wait, opening := h.opening[userID]; opening {
+			h.mu.Unlock()
+			<-wait
+			h.mu.Lock()
+			in, ok = h.inboxes[userID]
+			continue
+		}
+		done := make(chan struct{})
+		h.opening[userID] = done
+		conn := h.conn
+		h.mu.Unlock()
+
+		opened, err := h.openInbox(conn, userID)
+
+		h.mu.Lock()
+		delete(h.opening, userID)
+		close(done)
+		if err != nil {
+			h.mu.Unlock()
+			return nil, err
+		}
+		in, ok = opened, true
+		h.inboxes[userID] = in
+	}
+	defer h.mu.Unlock()
+	s := &Session{
+		Out:    make(chan Envelope, sessionBuffer),
+		userID: userID,
+		hub:    h,
+	}
+	in.sessions[s] = struct{}{}
+	return s, nil
+}
+
+// History returns the user's messages after since, marked as replayed.
+func (h *Hub) History(userID int, since string) []Envelope {
+	messages := h.history.Since(userID, since)
+	for i := range messages {
+		messages[i].Replay = true
+	}
+	return messages
+}
+
+// Deliver records a message in the user's history and sends it to every
+// socket of the user except skip.
+func (h *Hub) Deliver(userID int, env Envelope, skip *Session) {
+	if env.Type == TypeMessage {
+		h.history.Add(userID, env)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if in, ok := h.inboxes[userID]; ok {
+		h.fanOut(in, env, skip)
+	}
+}
+
+// ToAgents publishes a frame from the user to the agents.
+func (h *Hub) ToAgents(userID int, env Envelope) error {
+	body, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	h.publishMu.Lock()
+	defer h.publishMu.Unlock()
+	if h.publishCh == nil {
+		return rabbitmq.ErrNotConnected
+	}
+	return h.publishCh.Publish(h.exchange, "agent."+strconv.Itoa(userID), false, false, amqp.Publishing{
+		ContentType: "application/json",
+		MessageId:   env.ID,
+		Timestamp:   env.SentAt,
+		Body:        body,
+	})
+}
+
+// Send queues a frame for this socket only.
+func (s *Session) Send(env Envelope) {
+	h := s.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if in, ok := h.inboxes[s.userID]; ok && !s.closed {
+		h.send(in, s, env)
+	}
+}
+
+// Ack acknowledges an agent message to the broker.
+func (s *Session) Ack(id string) {
+	h := s.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	in, ok := h.inboxes[s.userID]
+	if !ok {
+		return
+	}
+	if tag, ok := in.pending[id]; ok {
+		delete(in.pending, id)
+		if err := in.ch.Ack(tag, false); err != nil {
+			log.Printf("help-desk ack of %s for user %d failed: %v", id, s.userID, err)
+		}
+	}
+}
+
+// Leave unregisters the socket. When the user's last socket leaves the
+// queue's channel is closed and unacknowledged messages go back to the
+// queue for the next connect.
+func (s *Session) Leave() {
+	h := s.hub
+	h.mu.Lock()
+	in, ok := h.inboxes[s.userID]
+	if !ok {
+		h.mu.Unlock()
+		return
+	}
+	h.drop(in, s)
+	last := len(in.sessions) == 0
+	if last {
+		delete(h.inboxes, s.userID)
+	}
+	h.mu.Unlock()
+	if last {
+		in.ch.Close()
+	}
+}
+
+// openInbox declares the user's queue and starts consuming it. It talks to
+// the broker, so h.mu must not be held.
+func (h *Hub) openInbox(conn *amqp.Connection, userID int) (*inbox, error) {
+	if conn == nil {
+		return nil, rabbitmq.ErrNotConnected
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("helpdesk.user.%d", userID)
+	args := amqp.Table{"x-expires": int32(h.inboxTTL.Milliseconds())}
+	if _, err := ch.QueueDeclare(name, true, false, false, false, args); err != nil {
+		ch.Close()
+		return nil, err
+	}
+	if err := ch.QueueBind(name, "user."+strconv.Itoa(userID), h.exchange, false, nil); err != nil {
+		ch.Close()
+		return nil, err
+	}
+	if err := ch.Qos(sessionBuffer, 0, false); err != nil {
+		ch.Close()
+		return nil, err
+	}
+	deliveries, err := ch.Consume(name, "", false, false, false, false, nil)
+	if err != nil {
+		ch.Close()
+		return nil, err
+	}
+	in := &inbox{
+		ch:       ch,
+		sessions: make(map[*Session]struct{}),
+		pending:  make(map[string]uint64),
+	}
+	go h.receive(userID, in, deliveries)
+	return in, nil
+}
+
+func (h *Hub) receive(userID int, in *inbox, deliveries <-chan amqp.Delivery) {
+	for d := range deliveries {
+		env := Envelope{}
+		if err := json.Unmarshal(d.Body, &env); err != nil {
+			log.Printf("help-desk message for user %d dropped: %v", userID, err)
+			d.Reject(false)
+			continue
+		}
+		switch env.Type {
+		case TypeMessage, TypeTyping, TypeTicketStatus:
+		default:
+			log.Printf("help-desk message for user %d with unknown type %q dropped", userID, env.Type)
+			d.Reject(false)
+			continue
+		}
+		env.From = FromAgent
+		env.UserID = userID
+		env.Replay = false
+		if env.ID == "" {
+			env.ID = deliveryID(d)
+		}
+		if env.SentAt.IsZero() {
+			env.SentAt = time.Now().UTC()
+		}
+		if env.Type == TypeMessage {
+			h.history.Add(userID, env)
+		}
+
+		h.mu.Lock()
+		if env.Type == TypeMessage {
+			in.pending[env.ID] = d.DeliveryTag
+		} else {
+			d.Ack(false)
+		}
+		h.fanOut(in, env, nil)
+		h.mu.Unlock()
+	}
+
+	// The channel is gone. If the sessions did not close it themselves,
+	// end them so their clients reconnect to a fresh queue consumer.
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.inboxes[userID] == in {
+		for s := range in.sessions {
+			h.drop(in, s)
+		}
+		delete(h.inboxes, userID)
+	}
+}
+
+// deliveryID names an agent message that carries no ID of its own. It is
+// derived from the delivery rather than generated, so a redelivery keeps
+// the ID the client may already have acknowledged or seen in history.
+func deliveryID(d amqp.Delivery) string {
+	if d.MessageId != "" {
+		return d.MessageId
+	}
+	if d.CorrelationId != "" {
+		return d.CorrelationId
+	}
+	// Identical bodies are told apart by the publish timestamp, which a
+	// redelivery keeps.
+	hash := sha256.New()
+	hash.Write([]byte(d.Timestamp.UTC().Format(time.RFC3339Nano)))
+	hash.Write(d.Body)
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
+
+// fanOut, send and drop expect h.mu to be held.
+func (h *Hub) fanOut(in *inbox, env Envelope, skip *Session) {
+	for s := range in.sessions {
+		if s != skip {
+			h.send(in, s, env)
+		}
+	}
+}
+
+func (h *Hub) send(in *inbox, s *Session, env Envelope) {
+	select {
+	case s.Out <- env:
+	default:
+		// The socket is not keeping up; the client reconnects and catches
+		// up from history.
+		h.drop(in, s)
+	}
+}
+
+func (h *Hub) drop(in *inbox, s *Session) {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	delete(in.sessions, s)
+	close(s.Out)
+}
@@ -0,0 +1,160 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aparnasukesh/api-gateway/pkg/common"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	KeyHeader      = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+	maxKeyLength   = 255
+	// maxBodySize bounds the request body buffered for the fingerprint.
+	maxBodySize = 1 << 20
+)
+
+var (
+	ErrKeyTooLong   = errors.New("Idempotency-Key must be at most 255 characters")
+	ErrKeyReused    = errors.New("Idempotency-Key was already used with a different request")
+	ErrKeyInFlight  = errors.New("a request with this Idempotency-Key is still being processed")
+	ErrBodyTooLarge = errors.New("request body is too large")
+)
+
+// Keys replays the first response for each Idempotency-Key and user.
+type Keys struct {
+	store Store
+	ttl   time.Duration
+}
+
+func NewKeys(store Store, ttl time.Duration) *Keys {
+	return &Keys{
+		store: store,
+		ttl:   ttl,
+	}
+}
+
+// Middleware must run after authentication; keys are scoped to the caller so
+// users cannot collide with, or read, each other's responses. Requests
+// without the header are passed through. Server errors are not stored, so
+// the client may retry them with the same key.
+func (k *Keys) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(KeyHeader)
+		if key == "" {
+			ctx.Next()
+			return
+		}
+		if len(key) > maxKeyLength {
+			common.ResponseWithError(ctx, http.StatusBadRequest, ErrKeyTooLong)
+			return
+		}
+		principal, ok := common.RequirePrincipal(ctx)
+		if !ok {
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxBodySize))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			common.ResponseWithError(ctx, http.StatusRequestEntityTooLarge, ErrBodyTooLarge)
+			return
+		}
+		if err != nil {
+			common.ResponseWithError(ctx, http.StatusBadRequest, err)
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+		fingerprint := fingerprint(ctx.Request.Method, ctx.Request.URL.Path, body)
+		storeKey := strconv.Itoa(principal.UserID) + ":" + key
+
+		existing, reserved, err := k.store.Reserve(ctx, storeKey, fingerprint, k.ttl)
+		if err != nil {
+			log.Printf("idempotency store failed, processing request %s without a key: %v", common.GetRequestID(ctx), err)
+			ctx.Next()
+			return
+		}
+		if !reserved {
+			switch {
+			case existing.Fingerprint != fingerprint:
+				common.ResponseWithError(ctx, http.StatusConflict, ErrKeyReused)
+			case !existing.Completed:
+				common.ResponseWithError(ctx, http.StatusConflict, ErrKeyInFlight)
+			default:
+				replay(ctx, existing)
+			}
+			return
+		}
+
+		defer func() {
+			if r := recover(); r != nil {
+				k.store.Release(ctx, storeKey)
+				panic(r)
+			}
+		}()
+		recorder := &responseRecorder{ResponseWriter: ctx.Writer}
+		ctx.Writer = recorder
+		ctx.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			err = k.store.Release(ctx, storeKey)
+		} else {
+			err = k.store.Complete(ctx, storeKey, &Record{
+				Fingerprint: fingerprint,
+				Completed:   true,
+				Status:      recorder.Status(),
+				Header:      recorder.Header().Clone(),
+				Body:        recorder.body.Bytes(),
+			}, k.ttl)
+		}
+		if err != nil {
+			log.Printf("idempotency store failed to save request %s: %v", common.GetRequestID(ctx), err)
+		}
+	}
+}
+
+func replay(ctx *gin.Context, record *Record) {
+	for name, values := range record.Header {
+		if name == common.RequestIDHeader {
+			continue
+		}
+		for _, value := range values {
+			ctx.Writer.Header().Add(name, value)
+		}
+	}
+	ctx.Header(ReplayedHeader, "true")
+	ctx.Status(record.Status)
+	ctx.Writer.Write(record.Body)
+	ctx.Abort()
+}
+
+func fingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
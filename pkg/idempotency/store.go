@@ -0,0 +1,92 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Record is what is kept per key: the request fingerprint and, once the
+// first request finished, its response.
+type Record struct {
+	Fingerprint string
+	Completed   bool
+	Status      int
+	Header      http.Header
+	Body        []byte
+}
+
+// Store keeps records for a TTL. Reserve must be atomic so that two
+// concurrent requests with the same key cannot both proceed.
+type Store interface {
+	// Reserve saves an in-flight record for key unless one exists, in which
+	// case the existing record is returned with reserved false.
+	Reserve(ctx context.Context, key string, fingerprint string, ttl time.Duration) (existing *Record, reserved bool, err error)
+	Complete(ctx context.Context, key string, record *Record, ttl time.Duration) error
+	Release(ctx context.Context, key string) error
+}
+
+type memoryEntry struct {
+	record  *Record
+	expires time.Time
+}
+
+// MemoryStore is a Store local to one gateway process.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]memoryEntry),
+	}
+}
+
+func (s *MemoryStore) Reserve(ctx context.Context, key string, fingerprint string, ttl time.Duration) (*Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+	if entry, ok := s.entries[key]; ok && !now.After(entry.expires) {
+		return entry.record, false, nil
+	}
+	s.entries[key] = memoryEntry{
+		record:  &Record{Fingerprint: fingerprint},
+		expires: now.Add(ttl),
+	}
+	return nil, true, nil
+}
+
+func (s *MemoryStore) Complete(ctx context.Context, key string, record *Record, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = memoryEntry{
+		record:  record,
+		expires: time.Now().Add(ttl),
+	}
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+// sweep drops expired entries at most once a minute. s.mu must be held.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, entry := range s.entries {
+		if now.After(entry.expires) {
+			delete(s.entries, key)
+		}
+	}
+}
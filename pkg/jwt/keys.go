@@ -0,0 +1,274 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+var ErrUnknownKey = errors.New("no verification key for token")
+
+// KeySource resolves the verification key for a token's alg and kid header.
+type KeySource interface {
+	Key(alg, kid string) (interface{}, error)
+}
+
+// SecretKeySource verifies HS256 tokens with a shared secret.
+type SecretKeySource struct {
+	secret []byte
+}
+
+func NewSecretKeySource(secret string) *SecretKeySource {
+	return &SecretKeySource{secret: []byte(secret)}
+}
+
+func (s *SecretKeySource) Key(alg, kid string) (interface{}, error) {
+	if alg != "HS256" {
+		return nil, ErrUnsupportedAlg
+	}
+	return s.secret, nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type keySet map[string]interface{}
+
+func (ks keySet) lookup(alg, kid string) (interface{}, error) {
+	if kid == "" && len(ks) == 1 {
+		for _, key := range ks {
+			return checkAlg(alg, key)
+		}
+	}
+	key, ok := ks[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return checkAlg(alg, key)
+}
+
+func checkAlg(alg string, key interface{}) (interface{}, error) {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		if alg == "RS256" {
+			return key, nil
+		}
+	case *ecdsa.PublicKey:
+		if alg == "ES256" {
+			return key, nil
+		}
+	}
+	return nil, ErrUnsupportedAlg
+}
+
+func parseJWKS(data []byte) (keySet, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error decoding jwks: %w", err)
+	}
+	ks := keySet{}
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("error decoding jwk %q: %w", k.Kid, err)
+		}
+		ks[k.Kid] = key
+	}
+	if len(ks) == 0 {
+		return nil, errors.New("jwks contains no signing keys")
+	}
+	return ks, nil
+}
+
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve P-256")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// JWKSFileKeySource verifies RS256/ES256 tokens against a JWKS document on disk.
+type JWKSFileKeySource struct {
+	keys keySet
+}
+
+func NewJWKSFileKeySource(path string) (*JWKSFileKeySource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading jwks file: %w", err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return nil, err
+	}
+	return &JWKSFileKeySource{keys: keys}, nil
+}
+
+func (s *JWKSFileKeySource) Key(alg, kid string) (interface{}, error) {
+	return s.keys.lookup(alg, kid)
+}
+
+// JWKSURLKeySource fetches a JWKS endpoint and caches it for refresh. Keys
+// are served from the cache under a read lock while a stale set is
+// refreshed in the background. An unknown kid triggers an early re-fetch
+// that concurrent callers share; fetch attempts, successful or not, are
+// spaced at least minRefetch apart so a down endpoint is not hammered.
+type JWKSURLKeySource struct {
+	url        string
+	client     *http.Client
+	refresh    time.Duration
+	minRefetch time.Duration
+
+	mu          sync.RWMutex
+	keys        keySet
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	fetchErr    error
+	// inflight is closed when the running fetch finishes.
+	inflight chan struct{}
+}
+
+func NewJWKSURLKeySource(url string, refresh time.Duration) *JWKSURLKeySource {
+	return &JWKSURLKeySource{
+		url:        url,
+		client:     &http.Client{Timeout: 5 * time.Second},
+		refresh:    refresh,
+		minRefetch: time.Minute,
+	}
+}
+
+func (s *JWKSURLKeySource) Key(alg, kid string) (interface{}, error) {
+	keys, fetchedAt, fetchErr := s.cached()
+	if keys == nil {
+		s.refetch(true)
+		if keys, _, fetchErr = s.cached(); keys == nil {
+			return nil, fetchErr
+		}
+	} else if time.Since(fetchedAt) > s.refresh {
+		s.refetch(false)
+	}
+	key, err := keys.lookup(alg, kid)
+	if errors.Is(err, ErrUnknownKey) && s.refetch(true) {
+		keys, _, _ = s.cached()
+		return keys.lookup(alg, kid)
+	}
+	return key, err
+}
+
+func (s *JWKSURLKeySource) cached() (keySet, time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.keys, s.fetchedAt, s.fetchErr
+}
+
+// refetch starts a fetch unless one is running or the last attempt was too
+// recent, joining the running one instead. With wait it blocks until that
+// fetch finishes and reports whether there was one to wait for.
+func (s *JWKSURLKeySource) refetch(wait bool) bool {
+	s.mu.Lock()
+	done := s.inflight
+	if done == nil {
+		if !s.attemptedAt.IsZero() && time.Since(s.attemptedAt) < s.minRefetch {
+			s.mu.Unlock()
+			return false
+		}
+		s.attemptedAt = time.Now()
+		done = make(chan struct{})
+		s.inflight = done
+		go s.load(done)
+	}
+	s.mu.Unlock()
+	if !wait {
+		return false
+	}
+	<-done
+	return true
+}
+
+func (s *JWKSURLKeySource) load(done chan struct{}) {
+	keys, err := s.fetch()
+	s.mu.Lock()
+	if err != nil {
+		s.fetchErr = err
+	} else {
+		s.keys = keys
+		s.fetchedAt = time.Now()
+		s.fetchErr = nil
+	}
+	s.inflight = nil
+	s.mu.Unlock()
+	close(done)
+}
+
+func (s *JWKSURLKeySource) fetch() (keySet, error) {
+	res, err := s.client.Get(s.url)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching jwks: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching jwks: unexpected status %d", res.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("error reading jwks: %w", err)
+	}
+	return parseJWKS(data)
+}
@@ -0,0 +1,290 @@
+package lockout
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aparnasukesh/api-gateway/pkg/common"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Config controls how failed logins are throttled. After FreeAttempts
+// failures each further attempt waits BackoffBase, doubled per failure up to
+// BackoffMax. Reaching a threshold locks the key for LockoutDuration.
+// Failures older than FailureWindow are forgotten.
+type Config struct {
+	FreeAttempts     int
+	BackoffBase      time.Duration
+	BackoffMax       time.Duration
+	AccountThreshold int
+	IPThreshold      int
+	LockoutDuration  time.Duration
+	FailureWindow    time.Duration
+}
+
+// Event is a security event raised when a key gets locked.
+type Event struct {
+	Type     string    `json:"type"`
+	Realm    string    `json:"realm"`
+	Key      string    `json:"key"`
+	Failures int       `json:"failures"`
+	Until    time.Time `json:"until"`
+}
+
+const (
+	EventAccountLocked = "login.account_locked"
+	EventIPLocked      = "login.ip_locked"
+)
+
+// BlockedError is returned by Begin while a key is backing off or locked.
+type BlockedError struct {
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+func (e *BlockedError) Error() string {
+	wait := e.RetryAfter.Round(time.Second)
+	if e.Locked {
+		return fmt.Sprintf("too many failed login attempts, try again in %s", wait)
+	}
+	return fmt.Sprintf("login attempted too soon after a failure, retry in %s", wait)
+}
+
+// Status is 423 for a lockout and 429 for a backoff delay.
+func (e *BlockedError) Status() int {
+	if e.Locked {
+		return http.StatusLocked
+	}
+	return http.StatusTooManyRequests
+}
+
+type entry struct {
+	failures    int
+	lastFailure time.Time
+	nextAttempt time.Time
+	lockedUntil time.Time
+	// inflight counts attempts that passed Begin and have not ended yet.
+	inflight int
+}
+
+// Guard tracks failed logins for one realm (user, admin or superadmin) by
+// account and by client IP.
+type Guard struct {
+	mu        sync.Mutex
+	realm     string
+	cfg       Config
+	entries   map[string]*entry
+	lastSweep time.Time
+	onEvent   func(Event)
+	now       func() time.Time
+}
+
+// NewGuard returns a Guard reporting events to onEvent, or to the log when
+// onEvent is nil.
+func NewGuard(realm string, cfg Config, onEvent func(Event)) *Guard {
+	if onEvent == nil {
+		onEvent = LogEvent
+	}
+	return &Guard{
+		realm:   realm,
+		cfg:     cfg,
+		entries: make(map[string]*entry),
+		onEvent: onEvent,
+		now:     time.Now,
+	}
+}
+
+// Attempt is a login reserved by Begin. End must be called once the
+// credentials have been checked.
+type Attempt struct {
+	guard   *Guard
+	account string
+	ip      string
+	once    sync.Once
+}
+
+// Begin reserves a login attempt for the account and the IP, or returns a
+// non-nil *BlockedError when either may not attempt a login yet. Attempts
+// in flight count as failures until they end, so concurrent requests cannot
+// slip past the backoff together.
+func (g *Guard) Begin(account, ip string) (*Attempt, *BlockedError) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	g.sweep(now)
+	keys := []struct {
+		key       string
+		threshold int
+	}{
+		{accountKey(account), g.cfg.AccountThreshold},
+		{ipKey(ip), g.cfg.IPThreshold},
+	}
+	var blocked *BlockedError
+	for _, k := range keys {
+		e, ok := g.entries[k.key]
+		if !ok {
+			continue
+		}
+		g.expire(now, e)
+		if now.Before(e.lockedUntil) {
+			wait := e.lockedUntil.Sub(now)
+			if blocked == nil || !blocked.Locked || wait > blocked.RetryAfter {
+				blocked = &BlockedError{Locked: true, RetryAfter: wait}
+			}
+			continue
+		}
+		if blocked != nil && blocked.Locked {
+			continue
+		}
+		wait := e.nextAttempt.Sub(now)
+		pending := e.failures + e.inflight
+		if e.inflight > 0 && (pending >= g.cfg.FreeAttempts || k.threshold > 0 && pending >= k.threshold) {
+			if wait < g.cfg.BackoffBase {
+				wait = g.cfg.BackoffBase
+			}
+		}
+		if wait > 0 && (blocked == nil || wait > blocked.RetryAfter) {
+			blocked = &BlockedError{RetryAfter: wait}
+		}
+	}
+	if blocked != nil {
+		return nil, blocked
+	}
+	for _, k := range keys {
+		e, ok := g.entries[k.key]
+		if !ok {
+			e = &entry{}
+			g.entries[k.key] = e
+		}
+		e.inflight++
+	}
+	return &Attempt{guard: g, account: account, ip: ip}, nil
+}
+
+// End releases the reservation and records its outcome: a nil err forgets
+// the account's failures, a credential failure counts against the account
+// and the IP, and any other error is not the caller's fault. The IP keeps
+// its count on success so one valid account cannot be used to reset
+// guessing against others.
+func (a *Attempt) End(err error) {
+	a.once.Do(func() {
+		a.guard.end(a.account, a.ip, err)
+	})
+}
+
+func (g *Guard) end(account, ip string, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	account, ip = accountKey(account), ipKey(ip)
+	for _, key := range []string{account, ip} {
+		if e, ok := g.entries[key]; ok && e.inflight > 0 {
+			e.inflight--
+		}
+	}
+	switch {
+	case err == nil:
+		if e, ok := g.entries[account]; ok {
+			if e.inflight == 0 {
+				delete(g.entries, account)
+			} else {
+				*e = entry{inflight: e.inflight}
+			}
+		}
+	case IsCredentialFailure(err):
+		g.fail(now, account, g.cfg.AccountThreshold, EventAccountLocked)
+		g.fail(now, ip, g.cfg.IPThreshold, EventIPLocked)
+	}
+}
+
+func (g *Guard) fail(now time.Time, key string, threshold int, eventType string) {
+	e := g.entries[key]
+	g.expire(now, e)
+	e.failures++
+	e.lastFailure = now
+
+	if threshold > 0 && e.failures >= threshold {
+		e.lockedUntil = now.Add(g.cfg.LockoutDuration)
+		g.onEvent(Event{
+			Type:     eventType,
+			Realm:    g.realm,
+			Key:      key,
+			Failures: e.failures,
+			Until:    e.lockedUntil,
+		})
+		return
+	}
+	if excess := e.failures - g.cfg.FreeAttempts; excess > 0 {
+		delay := g.cfg.BackoffBase << (excess - 1)
+		if delay > g.cfg.BackoffMax || delay <= 0 {
+			delay = g.cfg.BackoffMax
+		}
+		e.nextAttempt = now.Add(delay)
+	}
+}
+
+// expire starts the count over once the failures fall out of the window or
+// a lockout has run its course, so the next failure after a lock starts
+// from the free attempts again instead of relocking silently.
+func (g *Guard) expire(now time.Time, e *entry) {
+	lockExpired := !e.lockedUntil.IsZero() && !now.Before(e.lockedUntil)
+	if lockExpired || now.Sub(e.lastFailure) > g.cfg.FailureWindow {
+		*e = entry{inflight: e.inflight}
+	}
+}
+
+func (g *Guard) sweep(now time.Time) {
+	if now.Sub(g.lastSweep) < time.Minute {
+		return
+	}
+	g.lastSweep = now
+	for key, e := range g.entries {
+		if e.inflight == 0 && now.Sub(e.lastFailure) > g.cfg.FailureWindow && !now.Before(e.lockedUntil) {
+			delete(g.entries, key)
+		}
+	}
+}
+
+func accountKey(account string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(account))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// IsCredentialFailure reports whether a login error should count against the
+// caller. Upstream outages are not the caller's fault.
+func IsCredentialFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled, codes.ResourceExhausted, codes.Internal:
+		return false
+	}
+	return true
+}
+
+// Respond writes the error from Begin with a Retry-After header.
+func Respond(ctx *gin.Context, err *BlockedError) {
+	retryAfter := int(err.RetryAfter.Seconds() + 0.999)
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	ctx.Header("Retry-After", strconv.Itoa(retryAfter))
+	common.ResponseWithError(ctx, err.Status(), err)
+}
+
+// LogEvent writes the event to the standard logger as JSON.
+func LogEvent(event Event) {
+	data, _ := json.Marshal(event)
+	log.Printf("security event: %s", data)
+}
@@ -0,0 +1,88 @@
+package lockout
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var errBadPassword = errors.New("invalid credentials")
+
+func newTestGuard(events *[]Event) (*Guard, *time.Time) {
+	now := time.Unix(1_700_000_000, 0)
+	g := NewGuard("user", Config{
+		FreeAttempts:     2,
+		BackoffBase:      time.Second,
+		BackoffMax:       time.Minute,
+		AccountThreshold: 3,
+		IPThreshold:      10,
+		LockoutDuration:  time.Hour,
+		FailureWindow:    24 * time.Hour,
+	}, func(e Event) { *events = append(*events, e) })
+	g.now = func() time.Time { return now }
+	return g, &now
+}
+
+func TestGuardRelocksWithEvent(t *testing.T) {
+	var events []Event
+	g, now := newTestGuard(&events)
+
+	fail := func() *BlockedError {
+		attempt, blocked := g.Begin("a@example.com", "10.0.0.1")
+		if blocked != nil {
+			return blocked
+		}
+		attempt.End(errBadPassword)
+		return nil
+	}
+	for round := 1; round <= 2; round++ {
+		for i := 0; i < 3; i++ {
+			if blocked := fail(); blocked != nil {
+				t.Fatalf("round %d attempt %d: unexpected block %v", round, i+1, blocked)
+			}
+			*now = now.Add(10 * time.Second)
+		}
+		if blocked := fail(); blocked == nil || !blocked.Locked {
+			t.Fatalf("round %d: got %v, want a lockout", round, blocked)
+		}
+		if len(events) != round {
+			t.Fatalf("round %d: got %d events, want %d", round, len(events), round)
+		}
+		*now = now.Add(time.Hour)
+	}
+}
+
+func TestGuardReservesConcurrentAttempts(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		wantSame bool
+	}{
+		{name: "free attempts run concurrently", failures: 0, wantSame: true},
+		{name: "backoff allows one attempt at a time", failures: 1, wantSame: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events []Event
+			g, _ := newTestGuard(&events)
+			for i := 0; i < tt.failures; i++ {
+				attempt, _ := g.Begin("a@example.com", "10.0.0.1")
+				attempt.End(errBadPassword)
+			}
+
+			first, blocked := g.Begin("a@example.com", "10.0.0.1")
+			if blocked != nil {
+				t.Fatalf("first attempt blocked: %v", blocked)
+			}
+			_, blocked = g.Begin("a@example.com", "10.0.0.1")
+			if got := blocked == nil; got != tt.wantSame {
+				t.Fatalf("second attempt allowed = %v, want %v", got, tt.wantSame)
+			}
+
+			first.End(nil)
+			if _, blocked := g.Begin("a@example.com", "10.0.0.2"); blocked != nil {
+				t.Fatalf("attempt after success blocked: %v", blocked)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidLimit  = errors.New("limit must be between 1 and 100")
+)
+
+// Request is the page a client asked for. Cursor is already decoded; it is
+// whatever the list's cursor function produced for the last item of the
+// previous page.
+type Request struct {
+	Cursor string
+	Limit  int
+}
+
+// Page is one page of a sorted list. NextCursor is empty on the last page
+// and Total counts every item matching the filters.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      int    `json:"total"`
+}
+
+// FromQuery reads ?cursor= and ?limit=.
+func FromQuery(ctx *gin.Context) (Request, error) {
+	req := Request{Limit: DefaultLimit}
+	if limit := ctx.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > MaxLimit {
+			return req, ErrInvalidLimit
+		}
+		req.Limit = n
+	}
+	if cursor := ctx.Query("cursor"); cursor != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil || len(decoded) == 0 {
+			return req, ErrInvalidCursor
+		}
+		req.Cursor = string(decoded)
+	}
+	return req, nil
+}
+
+// Paginate cuts one page out of items, which must already be filtered and
+// sorted. The page starts at the first item for which after reports true;
+// after is only consulted when the request has a cursor. Comparing against
+// the cursor rather than looking up the last item keeps pages stable when
+// items are added or removed between requests.
+func Paginate[T any](items []T, req Request, cursorOf func(T) string, after func(T) bool) Page[T] {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	start := 0
+	if req.Cursor != "" {
+		start = len(items)
+		for i, item := range items {
+			if after(item) {
+				start = i
+				break
+			}
+		}
+	}
+	end := start + limit
+	if end > len(items) {
+		end = len(items)
+	}
+	page := Page[T]{
+		Items: items[start:end],
+		Total: len(items),
+	}
+	if end < len(items) {
+		page.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(cursorOf(items[end-1])))
+	}
+	return page
+}
@@ -0,0 +1,115 @@
+package pagination
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func queryContext(query url.Values) *gin.Context {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest("GET", "/?"+query.Encode(), nil)
+	return ctx
+}
+
+func TestFromQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   url.Values
+		want    Request
+		wantErr error
+	}{
+		{name: "defaults", query: url.Values{}, want: Request{Limit: DefaultLimit}},
+		{name: "limit", query: url.Values{"limit": {"5"}}, want: Request{Limit: 5}},
+		{name: "max limit", query: url.Values{"limit": {"100"}}, want: Request{Limit: MaxLimit}},
+		{name: "zero limit", query: url.Values{"limit": {"0"}}, wantErr: ErrInvalidLimit},
+		{name: "limit over max", query: url.Values{"limit": {"101"}}, wantErr: ErrInvalidLimit},
+		{name: "limit not a number", query: url.Values{"limit": {"ten"}}, wantErr: ErrInvalidLimit},
+		{name: "cursor", query: url.Values{"cursor": {"YToxOjI"}}, want: Request{Cursor: "a:1:2", Limit: DefaultLimit}},
+		{name: "cursor not base64", query: url.Values{"cursor": {"%%%"}}, wantErr: ErrInvalidCursor},
+		{name: "padded cursor", query: url.Values{"cursor": {"YToxOjI="}}, wantErr: ErrInvalidCursor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromQuery(queryContext(tt.query))
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && got != tt.want {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPaginateCursorRoundTrip walks a list page by page, feeding each
+// NextCursor back through FromQuery as a client would.
+func TestPaginateCursorRoundTrip(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5, 6, 7}
+	cursorOf := func(n int) string { return strconv.Itoa(n) }
+
+	tests := []struct {
+		name  string
+		limit int
+		// remove is dropped from the list after the first page, as if it
+		// were deleted between requests.
+		remove    int
+		wantPages [][]int
+	}{
+		{name: "even pages", limit: 7, wantPages: [][]int{{1, 2, 3, 4, 5, 6, 7}}},
+		{name: "last page short", limit: 3, wantPages: [][]int{{1, 2, 3}, {4, 5, 6}, {7}}},
+		{name: "one per page", limit: 1, wantPages: [][]int{{1}, {2}, {3}, {4}, {5}, {6}, {7}}},
+		{name: "cursor item deleted", limit: 3, remove: 3, wantPages: [][]int{{1, 2, 3}, {4, 5, 6}, {7}}},
+		{name: "next item deleted", limit: 3, remove: 4, wantPages: [][]int{{1, 2, 3}, {5, 6, 7}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := append([]int(nil), items...)
+			query := url.Values{"limit": {strconv.Itoa(tt.limit)}}
+			var pages [][]int
+			for len(pages) <= len(items) {
+				req, err := FromQuery(queryContext(query))
+				if err != nil {
+					t.Fatalf("page %d: %v", len(pages)+1, err)
+				}
+				after := func(n int) bool {
+					cursor, _ := strconv.Atoi(req.Cursor)
+					return n > cursor
+				}
+				page := Paginate(list, req, cursorOf, after)
+				if page.Total != len(list) {
+					t.Fatalf("page %d: total = %d, want %d", len(pages)+1, page.Total, len(list))
+				}
+				pages = append(pages, page.Items)
+				if page.NextCursor == "" {
+					break
+				}
+				query.Set("cursor", page.NextCursor)
+				if tt.remove != 0 && len(pages) == 1 {
+					for i, n := range list {
+						if n == tt.remove {
+							list = append(list[:i:i], list[i+1:]...)
+							break
+						}
+					}
+				}
+			}
+			if len(pages) != len(tt.wantPages) {
+				t.Fatalf("got pages %v, want %v", pages, tt.wantPages)
+			}
+			for i := range pages {
+				if len(pages[i]) != len(tt.wantPages[i]) {
+					t.Fatalf("got pages %v, want %v", pages, tt.wantPages)
+				}
+				for j := range pages[i] {
+					if pages[i][j] != tt.wantPages[i][j] {
+						t.Fatalf("got pages %v, want %v", pages, tt.wantPages)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/aparnasukesh/api-gateway/pkg/common"
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// Policy maps method and route patterns to the roles and permissions a
+// caller needs. Rules are evaluated in order and the first match decides.
+//
+//	default: allow
+//	roles:
+//	  admin: [theater:write, theater:read]
+//	  superadmin: ["*"]
+//	rules:
+//	  - method: DELETE
+//	    path: /gateway/superadmin/movie/:id
+//	    roles: [superadmin]
+//	    permissions: [movie:delete]
+type Policy struct {
+	// DryRun logs denials without enforcing them.
+	DryRun bool `yaml:"dry_run"`
+	// Default is "allow" or "deny" for routes no rule matches.
+	Default string              `yaml:"default"`
+	Roles   map[string][]string `yaml:"roles"`
+	Rules   []Rule              `yaml:"rules"`
+}
+
+// Rule matches a request by method ("*" or empty for any) and route pattern.
+// Path segments are compared with the gin route, so ":id" matches ":id";
+// "*" matches any one segment and a trailing "**" matches the remainder.
+type Rule struct {
+	Method      string   `yaml:"method"`
+	Path        string   `yaml:"path"`
+	Roles       []string `yaml:"roles"`
+	Permissions []string `yaml:"permissions"`
+}
+
+// Decision is the outcome of evaluating a request against the policy.
+type Decision struct {
+	Allowed bool
+	Rule    string
+	Reason  string
+	// Unauthenticated is set when the rule needs a principal and none was
+	// resolved.
+	Unauthenticated bool
+}
+
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	policy := &Policy{}
+	if err := yaml.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("policy %s: %w", path, err)
+	}
+	switch policy.Default {
+	case "":
+		policy.Default = "allow"
+	case "allow", "deny":
+	default:
+		return nil, fmt.Errorf("policy %s: default must be allow or deny, got %q", path, policy.Default)
+	}
+	for i, rule := range policy.Rules {
+		if rule.Path == "" {
+			return nil, fmt.Errorf("policy %s: rule %d has no path", path, i)
+		}
+	}
+	return policy, nil
+}
+
+// Evaluate decides whether principal, which may be nil, may call route.
+// route is the gin route pattern, not the request path.
+func (p *Policy) Evaluate(method, route string, principal *common.Principal) Decision {
+	rule, ok := p.match(method, route)
+	if !ok {
+		if p.Default == "deny" {
+			return Decision{Reason: "no policy rule matches the route"}
+		}
+		return Decision{Allowed: true}
+	}
+	name := rule.Method + " " + rule.Path
+	if len(rule.Roles) == 0 && len(rule.Permissions) == 0 {
+		return Decision{Allowed: true, Rule: name}
+	}
+	if principal == nil {
+		return Decision{Rule: name, Reason: "request is not authenticated", Unauthenticated: true}
+	}
+	if len(rule.Roles) > 0 && !contains(rule.Roles, principal.Role) {
+		return Decision{Rule: name, Reason: fmt.Sprintf("role %q is not allowed", principal.Role)}
+	}
+	granted := p.Roles[principal.Role]
+	for _, permission := range rule.Permissions {
+		if !contains(granted, "*") && !contains(granted, permission) {
+			return Decision{Rule: name, Reason: fmt.Sprintf("role %q lacks permission %q", principal.Role, permission)}
+		}
+	}
+	return Decision{Allowed: true, Rule: name}
+}
+
+// Middleware enforces the policy for the matched route. It runs after
+// authentication so the principal is available.
+func (p *Policy) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		principal, _ := common.GetPrincipal(ctx)
+		decision := p.Evaluate(ctx.Request.Method, ctx.FullPath(), principal)
+		if decision.Allowed {
+			ctx.Next()
+			return
+		}
+		if p.DryRun {
+			log.Printf("policy dry-run: would deny %s %s (rule %q, request %s): %s",
+				ctx.Request.Method, ctx.FullPath(), decision.Rule, common.GetRequestID(ctx), decision.Reason)
+			ctx.Next()
+			return
+		}
+		if decision.Unauthenticated {
+			common.ResponseWithError(ctx, http.StatusUnauthorized, errors.New("unauthorized: "+decision.Reason))
+			return
+		}
+		common.ResponseWithError(ctx, http.StatusForbidden, errors.New("forbidden: "+decision.Reason))
+	}
+}
+
+func (p *Policy) match(method, route string) (Rule, bool) {
+	for _, rule := range p.Rules {
+		if rule.Method != "" && rule.Method != "*" && !strings.EqualFold(rule.Method, method) {
+			continue
+		}
+		if matchPath(rule.Path, route) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+func matchPath(pattern, route string) bool {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	routeSegments := strings.Split(strings.Trim(route, "/"), "/")
+	for i, segment := range patternSegments {
+		if segment == "**" && i == len(patternSegments)-1 {
+			return true
+		}
+		if i >= len(routeSegments) {
+			return false
+		}
+		if segment != "*" && segment != routeSegments[i] {
+			return false
+		}
+	}
+	return len(patternSegments) == len(routeSegments)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
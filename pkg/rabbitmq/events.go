@@ -0,0 +1,166 @@
+package rabbitmq
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// Domain event types. They double as routing keys on the events exchange.
+const (
+	EventBookingCreated   = "booking.created"
+	EventPaymentSucceeded = "payment.succeeded"
+	EventPaymentFailed    = "payment.failed"
+	EventRefundRequested  = "refund.requested"
+	EventRefundUpdated    = "refund.updated"
+	EventUserRegistered   = "user.registered"
+	EventAdminApproved    = "admin.approved"
+	EventMovieRegistered  = "movie.registered"
+)
+
+// Event is the envelope every domain event is published in. Delivery is at
+// least once, so consumers should skip IDs they have already handled.
+type Event struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Version    int             `json:"version"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Payload is the data of one event type. EventVersion is bumped whenever
+// the payload changes in a way older consumers cannot read.
+type Payload interface {
+	EventType() string
+	EventVersion() int
+}
+
+// Keyed is implemented by payloads that describe something with its own
+// identity, such as a payment. Their events get an ID derived from the key,
+// so the same fact reported twice is published under one ID and consumers
+// skip the repeat.
+type Keyed interface {
+	EventKey() string
+}
+
+func NewEvent(data Payload) (Event, error) {
+	var id string
+	if keyed, ok := data.(Keyed); ok && keyed.EventKey() != "" {
+		id = data.EventType() + ":" + keyed.EventKey()
+	} else {
+		var err error
+		if id, err = correlationID(); err != nil {
+			return Event{}, err
+		}
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		ID:         id,
+		Type:       data.EventType(),
+		Version:    data.EventVersion(),
+		OccurredAt: time.Now().UTC(),
+		Data:       b,
+	}, nil
+}
+
+type BookingCreated struct {
+	BookingID   int      `json:"booking_id"`
+	UserID      int      `json:"user_id"`
+	ShowtimeID  int      `json:"showtime_id"`
+	SeatIDs     []uint32 `json:"seat_ids"`
+	TotalAmount float64  `json:"total_amount"`
+}
+
+func (BookingCreated) EventType() string { return EventBookingCreated }
+func (BookingCreated) EventVersion() int { return 1 }
+
+// PaymentSucceeded and PaymentFailed are raised both by the checkout
+// callbacks and by Razorpay webhooks, named in Source. Both reports of one
+// payment share an event ID derived from the PaymentID.
+type PaymentSucceeded struct {
+	OrderID   string `json:"order_id"`
+	PaymentID string `json:"payment_id"`
+	BookingID int    `json:"booking_id,omitempty"`
+	Source    string `json:"source"`
+}
+
+func (PaymentSucceeded) EventType() string  { return EventPaymentSucceeded }
+func (PaymentSucceeded) EventVersion() int  { return 1 }
+func (e PaymentSucceeded) EventKey() string { return e.PaymentID }
+
+type PaymentFailed struct {
+	OrderID   string `json:"order_id"`
+	PaymentID string `json:"payment_id"`
+	BookingID int    `json:"booking_id,omitempty"`
+	ErrorCode string `json:"error_code,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	Source    string `json:"source"`
+}
+
+func (PaymentFailed) EventType() string  { return EventPaymentFailed }
+func (PaymentFailed) EventVersion() int  { return 1 }
+func (e PaymentFailed) EventKey() string { return e.PaymentID }
+
+// RefundRequested records the refund owed for a cancelled booking until
+// payment-svc can issue refunds itself.
+type RefundRequested struct {
+	BookingID   int     `json:"booking_id"`
+	UserID      int     `json:"user_id"`
+	Amount      float64 `json:"amount"`
+	TotalAmount float64 `json:"total_amount"`
+	Fee         float64 `json:"fee"`
+}
+
+func (RefundRequested) EventType() string  { return EventRefundRequested }
+func (RefundRequested) EventVersion() int  { return 1 }
+func (e RefundRequested) EventKey() string { return strconv.Itoa(e.BookingID) }
+
+// RefundUpdated carries a Razorpay refund.* webhook until payment-svc can
+// record refunds itself. Amount is in paise; Status is Razorpay's.
+type RefundUpdated struct {
+	RefundID  string `json:"refund_id"`
+	PaymentID string `json:"payment_id"`
+	Amount    int64  `json:"amount"`
+	Status    string `json:"status"`
+	Trigger   string `json:"trigger"`
+	Source    string `json:"source"`
+}
+
+func (RefundUpdated) EventType() string  { return EventRefundUpdated }
+func (RefundUpdated) EventVersion() int  { return 1 }
+func (e RefundUpdated) EventKey() string { return e.RefundID + ":" + e.Status }
+
+// Payment event sources.
+const (
+	SourceCheckout = "checkout"
+	SourceWebhook  = "webhook"
+)
+
+type UserRegistered struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+func (UserRegistered) EventType() string { return EventUserRegistered }
+func (UserRegistered) EventVersion() int { return 1 }
+
+type AdminApproved struct {
+	Email string `json:"email"`
+}
+
+func (AdminApproved) EventType() string { return EventAdminApproved }
+func (AdminApproved) EventVersion() int { return 1 }
+
+type MovieRegistered struct {
+	MovieID     int    `json:"movie_id"`
+	Title       string `json:"title"`
+	Genre       string `json:"genre"`
+	Language    string `json:"language"`
+	ReleaseDate string `json:"release_date"`
+}
+
+func (MovieRegistered) EventType() string { return EventMovieRegistered }
+func (MovieRegistered) EventVersion() int { return 1 }
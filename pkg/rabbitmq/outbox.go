@@ -0,0 +1,226 @@
+package rabbitmq
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Outbox is a JSON-lines file of events waiting for the broker. It survives
+// restarts, so events spooled while RabbitMQ is down are sent once it is
+// back. Events that still fail after maxAttempts drains are moved to a
+// ".dead" file next to it so they stop holding back the rest.
+type Outbox struct {
+	path        string
+	maxAttempts int
+
+	mu sync.Mutex
+	n  int
+}
+
+// outboxEntry is one line of the file. Attempts counts the drains whose send
+// of the event failed while the broker was reachable.
+type outboxEntry struct {
+	Event
+	Attempts int `json:"attempts,omitempty"`
+}
+
+// OpenOutbox counts the events already spooled at path, creating its
+// directory if needed. The file is created on the first Append.
+func OpenOutbox(path string, maxAttempts int) (*Outbox, error) {
+	if maxAttempts <= 0 {
+		maxAttempts = 10
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	o := &Outbox{path: path, maxAttempts: maxAttempts}
+	entries, err := o.read()
+	if err != nil {
+		return nil, err
+	}
+	o.n = len(entries)
+	return o, nil
+}
+
+// Len is the number of spooled events.
+func (o *Outbox) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.n
+}
+
+// Append writes events to the end of the file and syncs it.
+func (o *Outbox) Append(events ...Event) error {
+	entries := make([]outboxEntry, len(events))
+	for i, evt := range events {
+		entries[i] = outboxEntry{Event: evt}
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := appendEntries(o.path, entries); err != nil {
+		return err
+	}
+	o.n += len(events)
+	return nil
+}
+
+// Drain sends spooled events in order until one fails, then keeps only the
+// events that were not sent. The file is locked while it is read and
+// rewritten but not during the sends, so Append is never held up by the
+// broker; events appended meanwhile are kept for the next drain. Drains must
+// not overlap. It returns how many events were sent.
+func (o *Outbox) Drain(send func(Event) error) (int, error) {
+	o.mu.Lock()
+	entries, err := o.read()
+	o.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	done := make(map[string]bool)
+	attempts := make(map[string]int)
+	dead := []outboxEntry{}
+	sent := 0
+	var sendErr error
+	for _, entry := range entries {
+		if done[entry.ID] {
+			continue
+		}
+		sendErr = send(entry.Event)
+		if sendErr == nil {
+			done[entry.ID] = true
+			sent++
+			continue
+		}
+		if errors.Is(sendErr, ErrNotConnected) {
+			break
+		}
+		entry.Attempts++
+		if entry.Attempts < o.maxAttempts {
+			attempts[entry.ID] = entry.Attempts
+			break
+		}
+		log.Printf("moving %s event %s to the dead letters after %d attempts: %v",
+			entry.Type, entry.ID, entry.Attempts, sendErr)
+		dead = append(dead, entry)
+		done[entry.ID] = true
+		sendErr = nil
+	}
+	if len(done) == 0 && len(attempts) == 0 {
+		return 0, sendErr
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(dead) > 0 {
+		if err := appendEntries(o.path+".dead", dead); err != nil {
+			return sent, err
+		}
+	}
+	// Read again so events appended during the sends are not lost.
+	current, err := o.read()
+	if err != nil {
+		return sent, err
+	}
+	kept := current[:0]
+	for _, entry := range current {
+		if done[entry.ID] {
+			continue
+		}
+		if n, ok := attempts[entry.ID]; ok {
+			entry.Attempts = n
+		}
+		kept = append(kept, entry)
+	}
+	if err := o.rewrite(kept); err != nil {
+		// The sent events stay in the file and go out again on the next
+		// drain, which consumers already tolerate.
+		return sent, err
+	}
+	o.n = len(kept)
+	return sent, sendErr
+}
+
+func (o *Outbox) read() ([]outboxEntry, error) {
+	f, err := os.Open(o.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := []outboxEntry{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		entry := outboxEntry{}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			// A torn write from a crash; skip it rather than block the rest.
+			log.Printf("dropped unreadable outbox entry in %s: %v", o.path, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// rewrite replaces the file atomically so a crash leaves either the old or
+// the new contents.
+func (o *Outbox) rewrite(entries []outboxEntry) error {
+	tmp, err := os.CreateTemp(filepath.Dir(o.path), filepath.Base(o.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	enc := json.NewEncoder(tmp)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), o.path)
+}
+
+// appendEntries writes entries to the end of the file at path and syncs it.
+func appendEntries(path string, entries []outboxEntry) error {
+	buf := bytes.Buffer{}
+	enc := json.NewEncoder(&buf)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
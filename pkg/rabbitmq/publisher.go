@@ -0,0 +1,298 @@
+package rabbitmq
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+var (
+	ErrEventNacked    = errors.New("broker rejected the event")
+	ErrConfirmTimeout = errors.New("timed out waiting for the broker to confirm the event")
+)
+
+type PublisherConfig struct {
+	// Exchange is a durable topic exchange; events are routed by type.
+	Exchange string
+	// Retries is how many more times an event is sent after a failure
+	// before it is spooled to the outbox.
+	Retries        int
+	RetryBackoff   time.Duration
+	ConfirmTimeout time.Duration
+	// FlushInterval is how often the outbox is retried while it has events.
+	FlushInterval time.Duration
+	// QueueSize bounds the events waiting in memory; beyond it Publish
+	// spools straight to the outbox.
+	QueueSize int
+}
+
+// Publisher sends domain events with publisher confirms from one background
+// goroutine, so callers never wait for the broker. Events that cannot be
+// confirmed after the retries, or that arrive while the outbox still holds
+// older ones, go to the outbox and are sent once the broker is reachable.
+// Ordering is best effort: an overflowing queue spools newer events first.
+// Attach it to every new connection with Manager.OnConnect.
+type Publisher struct {
+	cfg    PublisherConfig
+	outbox *Outbox
+	queue  chan Event
+	flush  chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+
+	closeMu sync.RWMutex
+	closed  bool
+
+	mu       sync.Mutex
+	conn     *amqp.Connection
+	ch       *amqp.Channel
+	confirms chan amqp.Confirmation
+}
+
+func NewPublisher(cfg PublisherConfig, outbox *Outbox) *Publisher {
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 30 * time.Second
+	}
+	p := &Publisher{
+		cfg:    cfg,
+		outbox: outbox,
+		queue:  make(chan Event, cfg.QueueSize),
+		flush:  make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// Attach declares the exchange on a new confirm channel of conn and sends
+// whatever the outbox collected while the broker was away.
+func (p *Publisher) Attach(conn *amqp.Connection) error {
+	p.mu.Lock()
+	p.resetChannel()
+	p.conn = conn
+	_, _, err := p.channel()
+	p.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	p.requestFlush()
+	return nil
+}
+
+// Publish queues the event built from data. It never fails the caller:
+// events that can be neither queued nor spooled are logged and dropped.
+func (p *Publisher) Publish(data Payload) {
+	evt, err := NewEvent(data)
+	if err != nil {
+		log.Printf("failed to build %s event: %v", data.EventType(), err)
+		return
+	}
+	p.closeMu.RLock()
+	defer p.closeMu.RUnlock()
+	if !p.closed {
+		select {
+		case p.queue <- evt:
+			return
+		default:
+		}
+	}
+	p.spool(evt)
+}
+
+// Persist writes the event built from data to the outbox before returning,
+// for events the caller must not act without, and lets the background
+// sender publish it. An error means the event was not recorded.
+func (p *Publisher) Persist(data Payload) error {
+	evt, err := NewEvent(data)
+	if err != nil {
+		return err
+	}
+	if err := p.outbox.Append(evt); err != nil {
+		return err
+	}
+	p.requestFlush()
+	return nil
+}
+
+// Close stops the background sender. Events still queued are spooled to
+// the outbox for the next start.
+func (p *Publisher) Close() {
+	p.closeMu.Lock()
+	if p.closed {
+		p.closeMu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.stop)
+	p.closeMu.Unlock()
+	<-p.done
+
+	p.mu.Lock()
+	p.resetChannel()
+	p.conn = nil
+	p.mu.Unlock()
+}
+
+func (p *Publisher) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(p.cfg.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case evt := <-p.queue:
+			p.deliver(evt)
+		case <-p.flush:
+			p.flushOutbox()
+		case <-ticker.C:
+			p.flushOutbox()
+		case <-p.stop:
+			for {
+				select {
+				case evt := <-p.queue:
+					p.spool(evt)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver keeps events in order by sending the outbox first and spooling
+// behind it when it cannot be emptied.
+func (p *Publisher) deliver(evt Event) {
+	if p.outbox.Len() > 0 {
+		p.flushOutbox()
+		if p.outbox.Len() > 0 {
+			p.spool(evt)
+			return
+		}
+	}
+	backoff := p.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := p.send(evt)
+		if err == nil {
+			return
+		}
+		if errors.Is(err, ErrNotConnected) || attempt >= p.cfg.Retries {
+			log.Printf("failed to publish %s event %s, spooling it: %v", evt.Type, evt.ID, err)
+			p.spool(evt)
+			return
+		}
+		select {
+		case <-time.After(backoff):
+		case <-p.stop:
+			p.spool(evt)
+			return
+		}
+		backoff *= 2
+	}
+}
+
+func (p *Publisher) flushOutbox() {
+	if p.outbox.Len() == 0 {
+		return
+	}
+	sent, err := p.outbox.Drain(p.send)
+	if sent > 0 {
+		log.Printf("published %d spooled events, %d left in the outbox", sent, p.outbox.Len())
+	}
+	if err != nil && !errors.Is(err, ErrNotConnected) {
+		log.Printf("failed to flush the event outbox: %v", err)
+	}
+}
+
+func (p *Publisher) spool(evt Event) {
+	if err := p.outbox.Append(evt); err != nil {
+		log.Printf("dropped %s event %s, outbox unavailable: %v", evt.Type, evt.ID, err)
+	}
+}
+
+func (p *Publisher) requestFlush() {
+	select {
+	case p.flush <- struct{}{}:
+	default:
+	}
+}
+
+// send publishes one event and waits for its confirm. Any failure throws
+// the channel away so a late confirm cannot be read as the next event's.
+func (p *Publisher) send(evt Event) error {
+	body, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ch, confirms, err := p.channel()
+	if err != nil {
+		return err
+	}
+	err = ch.Publish(p.cfg.Exchange, evt.Type, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    evt.ID,
+		Type:         evt.Type,
+		Timestamp:    evt.OccurredAt,
+		Headers:      amqp.Table{"version": int32(evt.Version)},
+		Body:         body,
+	})
+	if err != nil {
+		p.resetChannel()
+		return err
+	}
+	select {
+	case confirm, ok := <-confirms:
+		if !ok {
+			p.resetChannel()
+			return ErrNotConnected
+		}
+		if !confirm.Ack {
+			return ErrEventNacked
+		}
+		return nil
+	case <-time.After(p.cfg.ConfirmTimeout):
+		p.resetChannel()
+		return ErrConfirmTimeout
+	}
+}
+
+// channel returns the confirm channel, opening it on the attached
+// connection when needed. p.mu must be held.
+func (p *Publisher) channel() (*amqp.Channel, chan amqp.Confirmation, error) {
+	if p.ch != nil {
+		return p.ch, p.confirms, nil
+	}
+	if p.conn == nil || p.conn.IsClosed() {
+		return nil, nil, ErrNotConnected
+	}
+	ch, err := p.conn.Channel()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := ch.ExchangeDeclare(p.cfg.Exchange, "topic", true, false, false, false, nil); err != nil {
+		ch.Close()
+		return nil, nil, err
+	}
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, nil, err
+	}
+	p.ch = ch
+	p.confirms = ch.NotifyPublish(make(chan amqp.Confirmation, 1))
+	return p.ch, p.confirms, nil
+}
+
+// resetChannel closes the current channel. p.mu must be held.
+func (p *Publisher) resetChannel() {
+	if p.ch != nil {
+		p.ch.Close()
+	}
+	p.ch = nil
+	p.confirms = nil
+}
@@ -0,0 +1,170 @@
+package rabbitmq
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+var (
+	ErrRPCTimeout = errors.New("timed out waiting for a reply")
+	ErrRPCClosed  = errors.New("reply channel closed")
+)
+
+// RPCClient sends requests to a work queue and matches replies by
+// correlation ID. One channel and one reply consumer serve every call, so
+// callers only pay for a publish and a map entry per request. Attach it to
+// every new connection with Manager.OnConnect.
+type RPCClient struct {
+	queue   string
+	timeout time.Duration
+
+	mu      sync.Mutex
+	current *rpcChannel
+
+	pendingMu sync.Mutex
+	pending   map[string]chan amqp.Delivery
+}
+
+type rpcChannel struct {
+	ch      *amqp.Channel
+	replyTo string
+	done    chan struct{}
+}
+
+// NewRPCClient returns a client for queue. Calls without their own deadline
+// wait at most timeout for a reply.
+func NewRPCClient(queue string, timeout time.Duration) *RPCClient {
+	return &RPCClient{
+		queue:   queue,
+		timeout: timeout,
+		pending: make(map[string]chan amqp.Delivery),
+	}
+}
+
+// Attach declares the request queue and a private reply queue on a new
+// channel of conn and sends further calls through it.
+func (c *RPCClient) Attach(conn *amqp.Connection) error {
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	if _, err := ch.QueueDeclare(c.queue, false, false, false, false, nil); err != nil {
+		ch.Close()
+		return err
+	}
+	reply, err := ch.QueueDeclare("", false, true, true, false, nil)
+	if err != nil {
+		ch.Close()
+		return err
+	}
+	deliveries, err := ch.Consume(reply.Name, "", true, true, false, false, nil)
+	if err != nil {
+		ch.Close()
+		return err
+	}
+	rc := &rpcChannel{
+		ch:      ch,
+		replyTo: reply.Name,
+		done:    make(chan struct{}),
+	}
+	c.mu.Lock()
+	c.current = rc
+	c.mu.Unlock()
+	go c.dispatch(rc, deliveries)
+	return nil
+}
+
+// Call publishes body and waits for the matching reply, the timeout or ctx.
+func (c *RPCClient) Call(ctx context.Context, body []byte, contentType string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	id, err := correlationID()
+	if err != nil {
+		return nil, err
+	}
+	reply := make(chan amqp.Delivery, 1)
+	c.pendingMu.Lock()
+	c.pending[id] = reply
+	c.pendingMu.Unlock()
+	defer func() {
+		c.pendingMu.Lock()
+		delete(c.pending, id)
+		c.pendingMu.Unlock()
+	}()
+
+	// Holding mu also serializes publishes on the channel.
+	c.mu.Lock()
+	rc := c.current
+	if rc == nil {
+		c.mu.Unlock()
+		return nil, ErrNotConnected
+	}
+	err = rc.ch.Publish("", c.queue, false, false, amqp.Publishing{
+		ContentType:   contentType,
+		Body:          body,
+		ReplyTo:       rc.replyTo,
+		CorrelationId: id,
+		// Nobody waits for the answer after the timeout, so let the broker
+		// drop requests no worker picked up in time.
+		Expiration: strconv.FormatInt(c.timeout.Milliseconds(), 10),
+	})
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case msg := <-reply:
+		return msg.Body, nil
+	case <-rc.done:
+		return nil, ErrRPCClosed
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, ErrRPCTimeout
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// dispatch hands replies to their callers until the channel closes; calls
+// still waiting on it then return ErrRPCClosed.
+func (c *RPCClient) dispatch(rc *rpcChannel, deliveries <-chan amqp.Delivery) {
+	defer func() {
+		c.mu.Lock()
+		if c.current == rc {
+			c.current = nil
+		}
+		c.mu.Unlock()
+		close(rc.done)
+	}()
+	for msg := range deliveries {
+		c.pendingMu.Lock()
+		reply, ok := c.pending[msg.CorrelationId]
+		c.pendingMu.Unlock()
+		if !ok {
+			log.Printf("dropped reply for unknown or expired request %s on %s", msg.CorrelationId, c.queue)
+			continue
+		}
+		select {
+		case reply <- msg:
+		default:
+		}
+	}
+}
+
+func correlationID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreRefill(t *testing.T) {
+	limit := Limit{Requests: 2, Window: 2 * time.Second}
+	steps := []struct {
+		name          string
+		advance       time.Duration
+		wantAllowed   bool
+		wantRemaining int
+	}{
+		{"first request", 0, true, 1},
+		{"second request", 0, true, 0},
+		{"bucket empty", 0, false, 0},
+		{"half a token later", 500 * time.Millisecond, false, 0},
+		{"one token refilled", 500 * time.Millisecond, true, 0},
+		{"refilled to capacity", time.Minute, true, 1},
+	}
+	now := time.Unix(1700000000, 0)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		result, err := store.Take(context.Background(), "k", limit)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if result.Allowed != step.wantAllowed || result.Remaining != step.wantRemaining {
+			t.Errorf("%s: allowed=%v remaining=%d, want allowed=%v remaining=%d",
+				step.name, result.Allowed, result.Remaining, step.wantAllowed, step.wantRemaining)
+		}
+		if !result.Allowed && result.RetryAfter <= 0 {
+			t.Errorf("%s: denied without RetryAfter", step.name)
+		}
+	}
+}
+
+func TestMemoryStoreBurst(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Requests: 1, Window: time.Hour, Burst: 3}
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		result, _ := store.Take(context.Background(), "k", limit)
+		if result.Allowed {
+			allowed++
+		}
+	}
+	if allowed != 3 {
+		t.Errorf("allowed %d requests, want burst of 3", allowed)
+	}
+}
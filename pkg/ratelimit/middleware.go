@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aparnasukesh/api-gateway/pkg/common"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	APIKeyHeader = "X-API-Key"
+	appliedKey   = "ratelimit_applied"
+)
+
+var ErrTooManyRequests = errors.New("too many requests, retry later")
+
+type Limiter struct {
+	store   Store
+	rules   *Rules
+	apiKeys [][sha256.Size]byte
+}
+
+// NewLimiter returns a limiter for rules. Only the listed apiKeys get their
+// own bucket on api_key rules; callers sending any other key are limited by
+// IP, so rotating the header does not reset the limit.
+func NewLimiter(store Store, rules *Rules, apiKeys []string) *Limiter {
+	l := &Limiter{
+		store: store,
+		rules: rules,
+	}
+	for _, key := range apiKeys {
+		if key = strings.TrimSpace(key); key != "" {
+			l.apiKeys = append(l.apiKeys, sha256.Sum256([]byte(key)))
+		}
+	}
+	return l
+}
+
+// Middleware counts each request once against the rule for its route. It is
+// mounted globally and again after the auth middlewares: rules keyed by user
+// are skipped until a principal is known, so they only limit authenticated
+// routes. A nil Limiter lets everything through.
+func (l *Limiter) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if l == nil {
+			ctx.Next()
+			return
+		}
+		if _, done := ctx.Get(appliedKey); done {
+			ctx.Next()
+			return
+		}
+		rule, bucket := l.match(ctx.Request.Method, ctx.FullPath())
+		if rule == nil {
+			ctx.Next()
+			return
+		}
+		principal, authenticated := common.GetPrincipal(ctx)
+		if rule.Key == KeyUser && (!authenticated || principal.Resolve() != nil) {
+			ctx.Next()
+			return
+		}
+		ctx.Set(appliedKey, true)
+
+		var client, apiKey string
+		if rule.Key == KeyAPIKey {
+			apiKey = l.knownAPIKey(ctx.GetHeader(APIKeyHeader))
+		}
+		switch {
+		case rule.Key == KeyUser:
+			client = "user:" + strconv.Itoa(principal.UserID)
+		case apiKey != "":
+			client = "key:" + apiKey
+		default:
+			client = "ip:" + ctx.ClientIP()
+		}
+
+		result, err := l.store.Take(ctx, bucket+"|"+client, rule.Limit)
+		if err != nil {
+			log.Printf("rate limit store failed, allowing request %s: %v", common.GetRequestID(ctx), err)
+			ctx.Next()
+			return
+		}
+		ctx.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		ctx.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		ctx.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		if !result.Allowed {
+			retryAfter := ceilSeconds(result.RetryAfter)
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			ctx.Header("Retry-After", strconv.Itoa(retryAfter))
+			common.ResponseWithError(ctx, http.StatusTooManyRequests, ErrTooManyRequests)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// knownAPIKey returns a digest of key, which is used as its bucket name, or
+// "" when key is not configured.
+func (l *Limiter) knownAPIKey(key string) string {
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	for _, known := range l.apiKeys {
+		if subtle.ConstantTimeCompare(sum[:], known[:]) == 1 {
+			return hex.EncodeToString(sum[:8])
+		}
+	}
+	return ""
+}
+
+// match returns the rule for the route and the bucket name it counts into.
+func (l *Limiter) match(method, route string) (*Rule, string) {
+	if l.rules == nil || route == "" {
+		return nil, ""
+	}
+	for i := range l.rules.Routes {
+		rule := &l.rules.Routes[i]
+		if rule.matches(method, route) {
+			return rule, method + " " + route
+		}
+	}
+	if l.rules.Default != nil {
+		return l.rules.Default, "default"
+	}
+	return nil, ""
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,154 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeStore answers every Take with result and records the bucket keys.
+type fakeStore struct {
+	result Result
+	keys   []string
+}
+
+func (s *fakeStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.keys = append(s.keys, key)
+	return s.result, nil
+}
+
+func newRouter(l *Limiter) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(l.Middleware())
+	ok := func(ctx *gin.Context) { ctx.Status(http.StatusOK) }
+	r.POST("/login", ok)
+	r.GET("/movies", ok)
+	r.GET("/partner", ok)
+	return r
+}
+
+func request(r *gin.Engine, method, path, ip, apiKey string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = ip + ":1234"
+	if apiKey != "" {
+		req.Header.Set(APIKeyHeader, apiKey)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddlewarePerRouteLimits(t *testing.T) {
+	rules := &Rules{
+		Default: &Rule{Key: KeyIP, Limit: Limit{Requests: 3, Window: time.Minute}},
+		Routes: []Rule{
+			{Method: "POST", Path: "/login", Key: KeyIP, Limit: Limit{Requests: 1, Window: time.Minute}},
+		},
+	}
+	r := newRouter(NewLimiter(NewMemoryStore(), rules, nil))
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		ip         string
+		wantStatus int
+	}{
+		{"login allowed", "POST", "/login", "10.0.0.1", http.StatusOK},
+		{"login limited", "POST", "/login", "10.0.0.1", http.StatusTooManyRequests},
+		{"other client has its own bucket", "POST", "/login", "10.0.0.2", http.StatusOK},
+		{"default rule is separate from login", "GET", "/movies", "10.0.0.1", http.StatusOK},
+		{"default rule second", "GET", "/movies", "10.0.0.1", http.StatusOK},
+		{"default rule shared across routes", "GET", "/partner", "10.0.0.1", http.StatusOK},
+		{"default rule exhausted", "GET", "/movies", "10.0.0.1", http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		w := request(r, tt.method, tt.path, tt.ip, "")
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.wantStatus)
+		}
+	}
+}
+
+func TestMiddlewareHeaders(t *testing.T) {
+	tests := []struct {
+		name   string
+		result Result
+		status int
+		header map[string]string
+	}{
+		{
+			name:   "allowed",
+			result: Result{Allowed: true, Limit: 5, Remaining: 4, Reset: 12 * time.Second},
+			status: http.StatusOK,
+			header: map[string]string{"RateLimit-Limit": "5", "RateLimit-Remaining": "4", "RateLimit-Reset": "12", "Retry-After": ""},
+		},
+		{
+			name:   "denied rounds Retry-After up",
+			result: Result{Limit: 5, Remaining: 0, Reset: 60 * time.Second, RetryAfter: 1200 * time.Millisecond},
+			status: http.StatusTooManyRequests,
+			header: map[string]string{"RateLimit-Remaining": "0", "RateLimit-Reset": "60", "Retry-After": "2"},
+		},
+		{
+			name:   "denied never sends Retry-After 0",
+			result: Result{Limit: 5, RetryAfter: 10 * time.Millisecond},
+			status: http.StatusTooManyRequests,
+			header: map[string]string{"Retry-After": "1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := &Rules{Default: &Rule{Key: KeyIP, Limit: Limit{Requests: 5, Window: time.Minute}}}
+			r := newRouter(NewLimiter(&fakeStore{result: tt.result}, rules, nil))
+			w := request(r, "GET", "/movies", "10.0.0.1", "")
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			for name, want := range tt.header {
+				if got := w.Header().Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMiddlewareAPIKeyBuckets(t *testing.T) {
+	rules := &Rules{Default: &Rule{Key: KeyAPIKey, Limit: Limit{Requests: 1, Window: time.Minute}}}
+	tests := []struct {
+		name       string
+		apiKey     string
+		wantBucket string
+	}{
+		{"configured key", "partner-secret", "default|key:"},
+		{"unknown key falls back to ip", "rotated-1", "default|ip:10.0.0.1"},
+		{"no key uses ip", "", "default|ip:10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{result: Result{Allowed: true}}
+			r := newRouter(NewLimiter(store, rules, []string{"partner-secret"}))
+			request(r, "GET", "/partner", "10.0.0.1", tt.apiKey)
+			if len(store.keys) != 1 || !strings.HasPrefix(store.keys[0], tt.wantBucket) {
+				t.Fatalf("bucket = %v, want prefix %q", store.keys, tt.wantBucket)
+			}
+			if tt.apiKey != "" && strings.Contains(store.keys[0], tt.apiKey) {
+				t.Errorf("raw API key used in bucket name %q", store.keys[0])
+			}
+		})
+	}
+
+	r := newRouter(NewLimiter(NewMemoryStore(), rules, []string{"partner-secret"}))
+	if w := request(r, "GET", "/partner", "10.0.0.9", "rotated-1"); w.Code != http.StatusOK {
+		t.Fatalf("first request status = %d", w.Code)
+	}
+	if w := request(r, "GET", "/partner", "10.0.0.9", "rotated-2"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("rotating unknown keys was not limited: status = %d", w.Code)
+	}
+}
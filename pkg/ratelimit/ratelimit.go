@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Key kinds a rule can bucket requests by.
+const (
+	KeyIP     = "ip"
+	KeyUser   = "user"
+	KeyAPIKey = "api_key"
+)
+
+// Limit is a token bucket refilled with Requests tokens every Window and
+// holding at most Burst tokens. Burst defaults to Requests.
+type Limit struct {
+	Requests int           `yaml:"requests"`
+	Window   time.Duration `yaml:"window"`
+	Burst    int           `yaml:"burst"`
+}
+
+func (l Limit) capacity() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// Result describes the state of a bucket after one request was counted.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the next request would be allowed. It is
+	// zero when Allowed is true.
+	RetryAfter time.Duration
+}
+
+// Store keeps bucket state. The in-memory store is per process; a shared
+// store lets several gateway replicas enforce one limit.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Rule limits requests to one route. Method "*" or empty matches any method
+// and Path is the gin route pattern. Key selects how callers are told apart.
+type Rule struct {
+	Method string `yaml:"method"`
+	Path   string `yaml:"path"`
+	Key    string `yaml:"key"`
+	Limit  `yaml:",inline"`
+}
+
+// Rules is the rate limit file. Default, when set, applies to every route
+// without its own rule and is shared across those routes.
+//
+//	default: {key: ip, requests: 300, window: 1m}
+//	routes:
+//	  - {method: POST, path: /gateway/user/login, key: ip, requests: 5, window: 1m}
+type Rules struct {
+	Default *Rule  `yaml:"default"`
+	Routes  []Rule `yaml:"routes"`
+}
+
+func LoadRules(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rules := &Rules{}
+	if err := yaml.Unmarshal(data, rules); err != nil {
+		return nil, fmt.Errorf("rate limits %s: %w", path, err)
+	}
+	if rules.Default != nil {
+		if err := rules.Default.validate(); err != nil {
+			return nil, fmt.Errorf("rate limits %s: default: %w", path, err)
+		}
+	}
+	for i := range rules.Routes {
+		if err := rules.Routes[i].validate(); err != nil {
+			return nil, fmt.Errorf("rate limits %s: route %d: %w", path, i, err)
+		}
+	}
+	return rules, nil
+}
+
+func (r *Rule) validate() error {
+	if r.Key == "" {
+		r.Key = KeyIP
+	}
+	switch r.Key {
+	case KeyIP, KeyUser, KeyAPIKey:
+	default:
+		return fmt.Errorf("unknown key %q", r.Key)
+	}
+	if r.Requests <= 0 || r.Window <= 0 {
+		return fmt.Errorf("requests and window must be positive")
+	}
+	return nil
+}
+
+func (r *Rule) matches(method, route string) bool {
+	if r.Method != "" && r.Method != "*" && !strings.EqualFold(r.Method, method) {
+		return false
+	}
+	return r.Path == route
+}
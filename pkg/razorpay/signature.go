@@ -0,0 +1,45 @@
+package razorpay
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+var (
+	ErrMissingSignature = errors.New("razorpay signature is missing")
+	ErrInvalidSignature = errors.New("razorpay signature does not match")
+	ErrNoSecret         = errors.New("razorpay secret is not configured")
+)
+
+// VerifyPaymentSignature checks the razorpay_signature returned by Checkout,
+// the hex HMAC-SHA256 of "order_id|payment_id" keyed with the API key secret.
+func VerifyPaymentSignature(keySecret, orderID, paymentID, signature string) error {
+	return verify(keySecret, []byte(orderID+"|"+paymentID), signature)
+}
+
+func verify(secret string, payload []byte, signature string) error {
+	if secret == "" {
+		return ErrNoSecret
+	}
+	if signature == "" {
+		return ErrMissingSignature
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// VerifyWebhookSignature checks the X-Razorpay-Signature header, the hex
+// HMAC-SHA256 of the raw request body keyed with the webhook secret.
+func VerifyWebhookSignature(webhookSecret string, body []byte, signature string) error {
+	return verify(webhookSecret, body, signature)
+}
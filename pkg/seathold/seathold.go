@@ -0,0 +1,183 @@
+package seathold
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrSeatsHeld    = errors.New("one or more seats are held by another customer")
+	ErrHoldNotFound = errors.New("seat hold not found or expired")
+)
+
+// Hold reserves seats of one showtime for a user until ExpiresAt. BookingID
+// and OrderID are filled in as checkout progresses.
+type Hold struct {
+	ID         string    `json:"hold_id"`
+	UserID     int       `json:"user_id"`
+	ShowtimeID int       `json:"showtime_id"`
+	SeatIDs    []uint32  `json:"seat_ids"`
+	BookingID  int       `json:"booking_id,omitempty"`
+	OrderID    string    `json:"-"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Store keeps active holds. Create must be atomic so two holds can never
+// share a seat of the same showtime, and replaces the user's earlier holds
+// for the showtime that have no booking yet, returning them so their seats
+// can be announced as released.
+type Store interface {
+	Create(ctx context.Context, hold *Hold) ([]*Hold, error)
+	Get(ctx context.Context, id string) (*Hold, error)
+	Update(ctx context.Context, hold *Hold) error
+	Delete(ctx context.Context, id string) error
+	FindByOrder(ctx context.Context, orderID string) (*Hold, error)
+	FindByBooking(ctx context.Context, bookingID int) (*Hold, error)
+	// HeldSeats returns the seats of the showtime held by users other than
+	// exceptUser.
+	HeldSeats(ctx context.Context, showtimeID int, exceptUser int) (map[uint32]bool, error)
+	// Expired returns the holds that expired before now. They stay in the
+	// store until deleted, so a hold whose release fails is returned again.
+	Expired(ctx context.Context, now time.Time) ([]*Hold, error)
+}
+
+func NewID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// MemoryStore is a Store local to one gateway process.
+type MemoryStore struct {
+	mu    sync.Mutex
+	holds map[string]*Hold
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		holds: make(map[string]*Hold),
+	}
+}
+
+func (s *MemoryStore) Create(ctx context.Context, hold *Hold) ([]*Hold, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	replaced := []string{}
+	for id, existing := range s.holds {
+		if existing.ShowtimeID != hold.ShowtimeID || now.After(existing.ExpiresAt) {
+			continue
+		}
+		if existing.UserID == hold.UserID && existing.BookingID == 0 {
+			replaced = append(replaced, id)
+			continue
+		}
+		for _, held := range existing.SeatIDs {
+			for _, seat := range hold.SeatIDs {
+				if held == seat {
+					return nil, ErrSeatsHeld
+				}
+			}
+		}
+	}
+	released := make([]*Hold, 0, len(replaced))
+	for _, id := range replaced {
+		released = append(released, s.holds[id])
+		delete(s.holds, id)
+	}
+	s.holds[hold.ID] = copyHold(hold)
+	return released, nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Hold, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hold, ok := s.holds[id]
+	if !ok || time.Now().After(hold.ExpiresAt) {
+		return nil, ErrHoldNotFound
+	}
+	return copyHold(hold), nil
+}
+
+func (s *MemoryStore) Update(ctx context.Context, hold *Hold) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.holds[hold.ID]; !ok {
+		return ErrHoldNotFound
+	}
+	s.holds[hold.ID] = copyHold(hold)
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.holds, id)
+	return nil
+}
+
+func (s *MemoryStore) FindByOrder(ctx context.Context, orderID string) (*Hold, error) {
+	return s.find(func(hold *Hold) bool {
+		return orderID != "" && hold.OrderID == orderID
+	})
+}
+
+func (s *MemoryStore) FindByBooking(ctx context.Context, bookingID int) (*Hold, error) {
+	return s.find(func(hold *Hold) bool {
+		return bookingID != 0 && hold.BookingID == bookingID
+	})
+}
+
+func (s *MemoryStore) HeldSeats(ctx context.Context, showtimeID int, exceptUser int) (map[uint32]bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	seats := make(map[uint32]bool)
+	for _, hold := range s.holds {
+		if hold.ShowtimeID != showtimeID || hold.UserID == exceptUser || now.After(hold.ExpiresAt) {
+			continue
+		}
+		for _, seat := range hold.SeatIDs {
+			seats[seat] = true
+		}
+	}
+	return seats, nil
+}
+
+func (s *MemoryStore) Expired(ctx context.Context, now time.Time) ([]*Hold, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expired := []*Hold{}
+	for _, hold := range s.holds {
+		if now.After(hold.ExpiresAt) {
+			expired = append(expired, copyHold(hold))
+		}
+	}
+	return expired, nil
+}
+
+func (s *MemoryStore) find(match func(*Hold) bool) (*Hold, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, hold := range s.holds {
+		if match(hold) {
+			return copyHold(hold), nil
+		}
+	}
+	return nil, ErrHoldNotFound
+}
+
+func copyHold(hold *Hold) *Hold {
+	c := *hold
+	c.SeatIDs = append([]uint32(nil), hold.SeatIDs...)
+	return &c
+}
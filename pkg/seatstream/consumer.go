@@ -0,0 +1,59 @@
+package seatstream
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/streadway/amqp"
+)
+
+type seatMessage struct {
+	ShowtimeID int      `json:"showtime_id"`
+	SeatIDs    []uint32 `json:"seat_ids"`
+	State      string   `json:"state"`
+}
+
+// Consume binds a private queue to the booking events exchange and
+// publishes every seat message to the hub. Messages look like
+// {"showtime_id": 1, "seat_ids": [4, 5], "state": "booked"}.
+func Consume(conn *amqp.Connection, exchange, bindingKey string, hub *Hub) error {
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	if err := ch.ExchangeDeclare(exchange, "topic", true, false, false, false, nil); err != nil {
+		ch.Close()
+		return err
+	}
+	queue, err := ch.QueueDeclare("", false, true, true, false, nil)
+	if err != nil {
+		ch.Close()
+		return err
+	}
+	if err := ch.QueueBind(queue.Name, bindingKey, exchange, false, nil); err != nil {
+		ch.Close()
+		return err
+	}
+	deliveries, err := ch.Consume(queue.Name, "", true, true, false, false, nil)
+	if err != nil {
+		ch.Close()
+		return err
+	}
+	go func() {
+		for delivery := range deliveries {
+			msg := seatMessage{}
+			if err := json.Unmarshal(delivery.Body, &msg); err != nil {
+				log.Printf("seat event with routing key %s dropped: %v", delivery.RoutingKey, err)
+				continue
+			}
+			switch msg.State {
+			case StateHeld, StateBooked, StateReleased:
+				hub.Publish(msg.ShowtimeID, msg.SeatIDs, msg.State)
+			default:
+				log.Printf("seat event with unknown state %q dropped", msg.State)
+			}
+		}
+		log.Println("seat event consumer stopped")
+	}()
+	return nil
+}
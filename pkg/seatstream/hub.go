@@ -0,0 +1,174 @@
+package seatstream
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Seat states carried by events.
+const (
+	StateHeld     = "held"
+	StateBooked   = "booked"
+	StateReleased = "released"
+)
+
+const (
+	subscriberBuffer = 64
+	topicIdleTimeout = time.Hour
+)
+
+// Event is one seat state change of a showtime. Seq increases by one per
+// event of the showtime on this gateway instance.
+type Event struct {
+	Seq        uint64    `json:"seq"`
+	ShowtimeID int       `json:"showtime_id"`
+	SeatIDs    []uint32  `json:"seat_ids"`
+	State      string    `json:"state"`
+	At         time.Time `json:"at"`
+}
+
+// Subscription receives the events of one showtime. Events is closed when
+// the subscriber falls too far behind or is unsubscribed.
+type Subscription struct {
+	Events     chan Event
+	showtimeID int
+}
+
+type topic struct {
+	seq         uint64
+	history     []Event
+	subscribers map[*Subscription]struct{}
+	lastEvent   time.Time
+}
+
+// Hub fans seat events out to the streams of each showtime and keeps a short
+// history so reconnecting clients can resume without a new snapshot.
+type Hub struct {
+	mu         sync.Mutex
+	epoch      string
+	maxHistory int
+	topics     map[int]*topic
+	lastPrune  time.Time
+}
+
+func NewHub(maxHistory int) *Hub {
+	return &Hub{
+		epoch:      strconv.FormatInt(time.Now().UnixNano(), 36),
+		maxHistory: maxHistory,
+		topics:     make(map[int]*topic),
+	}
+}
+
+func (h *Hub) Publish(showtimeID int, seatIDs []uint32, state string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	h.prune(now)
+	t := h.topic(showtimeID)
+	t.seq++
+	t.lastEvent = now
+	event := Event{
+		Seq:        t.seq,
+		ShowtimeID: showtimeID,
+		SeatIDs:    seatIDs,
+		State:      state,
+		At:         now.UTC(),
+	}
+	t.history = append(t.history, event)
+	if len(t.history) > h.maxHistory {
+		t.history = t.history[len(t.history)-h.maxHistory:]
+	}
+	for sub := range t.subscribers {
+		select {
+		case sub.Events <- event:
+		default:
+			// Too slow; the client reconnects and resumes from history.
+			delete(t.subscribers, sub)
+			close(sub.Events)
+		}
+	}
+}
+
+// Subscribe registers a subscriber for the showtime. When lastEventID names
+// an event still in the history, the events after it are returned and
+// resumed is true. Otherwise the caller should send a snapshot; seq is the
+// sequence the snapshot corresponds to.
+func (h *Hub) Subscribe(showtimeID int, lastEventID string) (sub *Subscription, replay []Event, resumed bool, seq uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	t := h.topic(showtimeID)
+	sub = &Subscription{
+		Events:     make(chan Event, subscriberBuffer),
+		showtimeID: showtimeID,
+	}
+	t.subscribers[sub] = struct{}{}
+
+	if last, ok := h.parseEventID(lastEventID); ok && last <= t.seq {
+		oldest := t.seq - uint64(len(t.history))
+		if last >= oldest {
+			for _, event := range t.history {
+				if event.Seq > last {
+					replay = append(replay, event)
+				}
+			}
+			return sub, replay, true, t.seq
+		}
+	}
+	return sub, nil, false, t.seq
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	t, ok := h.topics[sub.showtimeID]
+	if !ok {
+		return
+	}
+	if _, ok := t.subscribers[sub]; ok {
+		delete(t.subscribers, sub)
+		close(sub.Events)
+	}
+}
+
+// EventID is the SSE id for seq. It includes the hub's epoch so IDs handed
+// out by another instance, or before a restart, are not mistaken for ours.
+func (h *Hub) EventID(seq uint64) string {
+	return h.epoch + "." + strconv.FormatUint(seq, 10)
+}
+
+func (h *Hub) parseEventID(id string) (uint64, bool) {
+	epoch, seq, found := strings.Cut(id, ".")
+	if !found || epoch != h.epoch {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	return n, err == nil
+}
+
+func (h *Hub) topic(showtimeID int) *topic {
+	t, ok := h.topics[showtimeID]
+	if !ok {
+		t = &topic{
+			subscribers: make(map[*Subscription]struct{}),
+			lastEvent:   time.Now(),
+		}
+		h.topics[showtimeID] = t
+	}
+	return t
+}
+
+func (h *Hub) prune(now time.Time) {
+	if now.Sub(h.lastPrune) < time.Minute {
+		return
+	}
+	h.lastPrune = now
+	for id, t := range h.topics {
+		if len(t.subscribers) == 0 && now.Sub(t.lastEvent) > topicIdleTimeout {
+			delete(h.topics, id)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package ticket
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/skip2/go-qrcode"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const qrSize = 280
+
+// lines is the text printed next to the QR code.
+func (t *Ticket) lines() []string {
+	return []string{
+		t.Movie,
+		fmt.Sprintf("%s, %s", t.Theater, t.City),
+		fmt.Sprintf("Screen %d", t.Screen),
+		t.ShowTime.Format("Mon 02 Jan 2006, 15:04"),
+		"Seats: " + strings.Join(t.Seats, ", "),
+		fmt.Sprintf("Amount: %.2f", t.TotalAmount),
+		"Booking ref: " + t.Reference,
+	}
+}
+
+// RenderPNG draws the ticket details beside a QR code of token.
+func RenderPNG(t *Ticket, token string) ([]byte, error) {
+	qr, err := qrcode.New(token, qrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+	code := qr.Image(qrSize)
+
+	img := image.NewRGBA(image.Rect(0, 0, 760, qrSize+40))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(460, 20, 460+qrSize, 20+qrSize), code, image.Point{}, draw.Src)
+
+	drawer := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(color.Black),
+		Face: basicfont.Face7x13,
+	}
+	for i, line := range t.lines() {
+		drawer.Dot = fixed.P(24, 48+i*30)
+		drawer.DrawString(line)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderPDF lays the ticket out on one A6 landscape page.
+func RenderPDF(t *Ticket, token string) ([]byte, error) {
+	qr, err := qrcode.Encode(token, qrcode.Medium, qrSize)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf := gofpdf.New("L", "mm", "A6", "")
+	pdf.SetTitle("E-ticket "+t.Reference, true)
+	pdf.AddPage()
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.MultiCell(80, 7, tr(t.Movie), "", "L", false)
+	pdf.SetFont("Helvetica", "", 10)
+	for _, line := range t.lines()[1:] {
+		pdf.MultiCell(80, 6, tr(line), "", "L", false)
+	}
+
+	pdf.RegisterImageOptionsReader("qr", gofpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(qr))
+	pdf.ImageOptions("qr", 92, 12, 50, 50, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	pdf.SetXY(92, 63)
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.CellFormat(50, 5, t.Reference, "", 0, "C", false, 0, "")
+
+	buf := &bytes.Buffer{}
+	if err := pdf.Output(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
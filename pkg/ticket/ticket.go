@@ -0,0 +1,119 @@
+package ticket
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TokenPrefix marks the version of the QR payload format.
+const TokenPrefix = "MT1"
+
+var (
+	ErrNoSigningKey = errors.New("ticket signing key is not configured")
+	ErrInvalidToken = errors.New("invalid ticket token")
+)
+
+// Ticket is everything printed on an e-ticket.
+type Ticket struct {
+	Reference   string
+	BookingID   int
+	ShowtimeID  int
+	Movie       string
+	Theater     string
+	City        string
+	Screen      int
+	ShowTime    time.Time
+	Seats       []string
+	TotalAmount float64
+	IssuedAt    time.Time
+}
+
+func Reference(bookingID int) string {
+	return fmt.Sprintf("BK%08d", bookingID)
+}
+
+// Claims is the signed content of the QR code. It carries enough for staff
+// to check a ticket without calling the gateway.
+type Claims struct {
+	Reference  string   `json:"ref"`
+	BookingID  int      `json:"bid"`
+	ShowtimeID int      `json:"sid"`
+	Seats      []string `json:"seats"`
+	ShowTime   int64    `json:"show"`
+	IssuedAt   int64    `json:"iat"`
+}
+
+// Signer signs QR payloads with an Ed25519 key, so scanners only need the
+// public key to validate tickets offline.
+type Signer struct {
+	key ed25519.PrivateKey
+}
+
+// NewSigner takes a base64 encoded 32 byte Ed25519 seed.
+func NewSigner(seed string) (*Signer, error) {
+	raw, err := base64.StdEncoding.DecodeString(seed)
+	if err != nil {
+		return nil, fmt.Errorf("ticket signing key: %w", err)
+	}
+	if len(raw) != ed25519.SeedSize {
+		return nil, fmt.Errorf("ticket signing key: want %d bytes, got %d", ed25519.SeedSize, len(raw))
+	}
+	return &Signer{key: ed25519.NewKeyFromSeed(raw)}, nil
+}
+
+// PublicKey is the base64 encoded key scanners validate tokens with.
+func (s *Signer) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey))
+}
+
+// Sign returns "MT1.<claims>.<signature>", both parts base64url encoded.
+func (s *Signer) Sign(t *Ticket) (string, error) {
+	if s == nil {
+		return "", ErrNoSigningKey
+	}
+	payload, err := json.Marshal(Claims{
+		Reference:  t.Reference,
+		BookingID:  t.BookingID,
+		ShowtimeID: t.ShowtimeID,
+		Seats:      t.Seats,
+		ShowTime:   t.ShowTime.Unix(),
+		IssuedAt:   t.IssuedAt.Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	signed := TokenPrefix + "." + base64.RawURLEncoding.EncodeToString(payload)
+	signature := ed25519.Sign(s.key, []byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Verify checks a token against the base64 encoded public key and returns
+// its claims.
+func Verify(publicKey, token string) (*Claims, error) {
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("ticket public key: invalid")
+	}
+	i := strings.LastIndex(token, ".")
+	if i < 0 || !strings.HasPrefix(token, TokenPrefix+".") {
+		return nil, ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(token[i+1:])
+	if err != nil || !ed25519.Verify(key, []byte(token[:i]), signature) {
+		return nil, ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(token[len(TokenPrefix)+1 : i])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	claims := &Claims{}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
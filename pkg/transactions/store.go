@@ -0,0 +1,63 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrUnknownTransaction = errors.New("transaction not found")
+
+// Store maps transactions to the booking they pay for. payment-svc does not
+// report either for a transaction, so the gateway records the link when it
+// starts a payment and checks ownership on the booking. Deployments with
+// more than one replica need a Store shared between them.
+type Store interface {
+	Put(ctx context.Context, transactionID, bookingID int, ttl time.Duration) error
+	BookingOf(ctx context.Context, transactionID int) (int, error)
+}
+
+type memoryEntry struct {
+	bookingID int
+	expires   time.Time
+}
+
+// MemoryStore is a Store local to one gateway process.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[int]memoryEntry
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[int]memoryEntry),
+	}
+}
+
+func (s *MemoryStore) Put(ctx context.Context, transactionID, bookingID int, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, entry := range s.entries {
+		if now.After(entry.expires) {
+			delete(s.entries, id)
+		}
+	}
+	s.entries[transactionID] = memoryEntry{
+		bookingID: bookingID,
+		expires:   now.Add(ttl),
+	}
+	return nil
+}
+
+func (s *MemoryStore) BookingOf(ctx context.Context, transactionID int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[transactionID]
+	if !ok || time.Now().After(entry.expires) {
+		return 0, ErrUnknownTransaction
+	}
+	return entry.bookingID, nil
+}